@@ -26,36 +26,44 @@ type LoggerInterface interface {
 	Error(v ...interface{})
 }
 
+// helperLogger returns the logger for the package-level helpers below.
+func helperLogger() LoggerInterface {
+	if a, ok := Logger.(*LogsLogger); ok && a.helpers != nil {
+		return a.helpers
+	}
+	return Logger
+}
+
 func Debugf(format string, params ...interface{}) {
-	Logger.Debugf(format, params...)
+	helperLogger().Debugf(format, params...)
 }
 
 func Infof(format string, params ...interface{}) {
-	Logger.Debugf(format, params...)
+	helperLogger().Infof(format, params...)
 }
 
 func Warnf(format string, params ...interface{}) {
-	Logger.Warnf(format, params...)
+	helperLogger().Warnf(format, params...)
 }
 
 func Errorf(format string, params ...interface{}) {
-	Logger.Errorf(format, params...)
+	helperLogger().Errorf(format, params...)
 }
 
 func Debug(v ...interface{}) {
-	Logger.Debug(v...)
+	helperLogger().Debug(v...)
 }
 
 func Info(v ...interface{}) {
-	Logger.Info(v...)
+	helperLogger().Info(v...)
 }
 
 func Warn(v ...interface{}) {
-	Logger.Warn(v...)
+	helperLogger().Warn(v...)
 }
 
 func Error(v ...interface{}) {
-	Logger.Error(v...)
+	helperLogger().Error(v...)
 }
 
 type DefaultLogger struct {
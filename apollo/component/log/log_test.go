@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+type recordingLogger struct {
+	calls []string
+}
+
+func (r *recordingLogger) record(method string, msg string) {
+	r.calls = append(r.calls, method+" "+msg)
+}
+
+func (r *recordingLogger) Debugf(format string, params ...interface{}) {
+	r.record("Debugf", fmt.Sprintf(format, params...))
+}
+
+func (r *recordingLogger) Infof(format string, params ...interface{}) {
+	r.record("Infof", fmt.Sprintf(format, params...))
+}
+
+func (r *recordingLogger) Warnf(format string, params ...interface{}) {
+	r.record("Warnf", fmt.Sprintf(format, params...))
+}
+
+func (r *recordingLogger) Errorf(format string, params ...interface{}) {
+	r.record("Errorf", fmt.Sprintf(format, params...))
+}
+
+func (r *recordingLogger) Debug(v ...interface{}) { r.record("Debug", fmt.Sprint(v...)) }
+func (r *recordingLogger) Info(v ...interface{})  { r.record("Info", fmt.Sprint(v...)) }
+func (r *recordingLogger) Warn(v ...interface{})  { r.record("Warn", fmt.Sprint(v...)) }
+func (r *recordingLogger) Error(v ...interface{}) { r.record("Error", fmt.Sprint(v...)) }
+
+func Test_PackageLevels(t *testing.T) {
+	defer func(l LoggerInterface) { Logger = l }(Logger)
+	rec := &recordingLogger{}
+	InitLogger(rec)
+
+	Debugf("d%d", 1)
+	Infof("i%d", 1)
+	Warnf("w%d", 1)
+	Errorf("e%d", 1)
+	Debug("d", 2)
+	Info("i", 2)
+	Warn("w", 2)
+	Error("e", 2)
+
+	want := []string{"Debugf d1", "Infof i1", "Warnf w1", "Errorf e1", "Debug d2", "Info i2", "Warn w2", "Error e2"}
+	if strings.Join(rec.calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("got calls %v, want %v", rec.calls, want)
+	}
+}
+
+func Test_LogsLogger(t *testing.T) {
+	defer func(l LoggerInterface) { Logger = l }(Logger)
+	var buf bytes.Buffer
+	l := logs.New()
+	l.SetOutput(&buf)
+	InitLogger(NewLogsLogger(l))
+
+	Debugf("hidden")
+	Infof("config %s loaded", "app")
+	Warn("slow ", "sync")
+	Errorf("sync failed")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	for i, want := range []string{"[INFO] ", "[WARN] ", "[ERROR] "} {
+		if !strings.HasPrefix(lines[i], want) || !strings.Contains(lines[i], "log_test.go:") ||
+			!strings.Contains(lines[i], "[Test_LogsLogger] apollo ") {
+			t.Fatalf("line %d: unexpected %q", i, lines[i])
+		}
+	}
+	if !strings.HasSuffix(lines[0], "config app loaded") || !strings.HasSuffix(lines[1], "slow sync") {
+		t.Fatalf("unexpected messages %q", lines)
+	}
+
+	// Calling the adapter directly reports the caller as well.
+	buf.Reset()
+	Logger.Infof("direct")
+	if !strings.Contains(buf.String(), "log_test.go:") || !strings.Contains(buf.String(), "[Test_LogsLogger] apollo direct") {
+		t.Fatalf("direct call reported the wrong caller: %q", buf.String())
+	}
+
+	l.Named("apollo").SetLevel(logs.LevelDebug)
+	buf.Reset()
+	Debug("visible")
+	if !strings.Contains(buf.String(), "[DEBUG] ") {
+		t.Fatalf("apollo level was not applied: %q", buf.String())
+	}
+}
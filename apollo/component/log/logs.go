@@ -0,0 +1,69 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+// Caller depths making the logs.Logger report the caller of a LogsLogger
+// method, and of the package-level helpers such as Infof, which is how the
+// apollo client logs.
+const (
+	callerDepth       = 3
+	helperCallerDepth = 4
+)
+
+// LogsLogger implements LoggerInterface on a logs.Logger named "apollo", so
+// apollo levels map onto logs levels and can be set through logs.Named.
+type LogsLogger struct {
+	logger *logs.Logger
+
+	// helpers is used by the package-level helpers, which add a frame.
+	helpers *LogsLogger
+}
+
+// NewLogsLogger returns an adapter for a child of l, or of the default
+// logs.Logger when l is nil. Install it with InitLogger.
+func NewLogsLogger(l *logs.Logger) *LogsLogger {
+	if l == nil {
+		l = logs.Default()
+	}
+	direct := l.Named("apollo")
+	direct.SetCallerDepth(callerDepth)
+	helpers := l.Named("apollo")
+	helpers.SetCallerDepth(helperCallerDepth)
+	return &LogsLogger{logger: direct, helpers: &LogsLogger{logger: helpers}}
+}
+
+func (a *LogsLogger) Debugf(format string, params ...interface{}) {
+	a.logger.Debug(format, params...)
+}
+
+func (a *LogsLogger) Infof(format string, params ...interface{}) {
+	a.logger.Info(format, params...)
+}
+
+func (a *LogsLogger) Warnf(format string, params ...interface{}) {
+	a.logger.Warn(format, params...)
+}
+
+func (a *LogsLogger) Errorf(format string, params ...interface{}) {
+	a.logger.Error(format, params...)
+}
+
+func (a *LogsLogger) Debug(v ...interface{}) {
+	a.logger.Debug("%s", fmt.Sprint(v...))
+}
+
+func (a *LogsLogger) Info(v ...interface{}) {
+	a.logger.Info("%s", fmt.Sprint(v...))
+}
+
+func (a *LogsLogger) Warn(v ...interface{}) {
+	a.logger.Warn("%s", fmt.Sprint(v...))
+}
+
+func (a *LogsLogger) Error(v ...interface{}) {
+	a.logger.Error("%s", fmt.Sprint(v...))
+}
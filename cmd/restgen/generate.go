@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type generator struct {
+	doc     *Document
+	buf     bytes.Buffer
+	imports map[string]bool
+}
+
+// Generate renders the schemas and operations of doc as a Go file of
+// package pkg.
+func Generate(doc *Document, pkg string) ([]byte, error) {
+	g := &generator{doc: doc, imports: map[string]bool{}}
+	if err := g.genSchemas(); err != nil {
+		return nil, err
+	}
+	g.genClient()
+	if err := g.genOperations(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by restgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString("\n\t\"github.com/f0resee/stdlib/rest\"\n)\n\n")
+	out.Write(g.buf.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return code, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// use records a standard library import needed by the code being emitted.
+func (g *generator) use(path string) {
+	g.imports[path] = true
+}
+
+func (g *generator) comment(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		g.printf("// %s\n", strings.TrimSpace(line))
+	}
+}
+
+func (g *generator) genSchemas() error {
+	names := make([]string, 0, len(g.doc.Components.Schemas))
+	for name := range g.doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema := g.doc.Components.Schemas[name]
+		typeName := exportName(name)
+		if schema.Description != "" {
+			g.comment(schema.Description)
+		}
+
+		if schema.Type.Name == "string" && len(schema.Enum) > 0 {
+			g.printf("type %s string\n\nconst (\n", typeName)
+			for _, value := range schema.Enum {
+				s := fmt.Sprint(value)
+				g.printf("\t%s%s %s = %q\n", typeName, exportName(s), typeName, s)
+			}
+			g.printf(")\n\n")
+			continue
+		}
+
+		goType, err := g.goType(schema)
+		if err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+		g.printf("type %s %s\n\n", typeName, goType)
+	}
+	return nil
+}
+
+func (g *generator) genClient() {
+	g.use("context")
+	g.use("errors")
+	g.printf(`// Client is a typed client built on rest.RESTClient. Responses with a
+// non-2xx status are returned as *rest.StatusError. An empty response body,
+// as sent with 204 No Content, yields the zero value of the result type.
+type Client struct {
+	client *rest.RESTClient
+}
+
+func NewClient(client *rest.RESTClient) *Client {
+	return &Client{client: client}
+}
+
+// do sends req and returns the response body. rest.Request only treats
+// 200 OK as success; the other 2xx statuses are successes here too.
+func (c *Client) do(ctx context.Context, req *rest.Request) ([]byte, error) {
+	raw, err := req.Do(ctx).Raw()
+	var statusErr *rest.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= 200 && statusErr.StatusCode < 300 {
+		return raw, nil
+	}
+	return raw, err
+}
+
+`)
+}
+
+func (g *generator) genOperations() error {
+	paths := make([]string, 0, len(g.doc.Paths))
+	for p := range g.doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		item := g.doc.Paths[p]
+		for _, op := range item.operations() {
+			if err := g.genOperation(p, op.method, item, op.operation); err != nil {
+				return fmt.Errorf("%s %s: %w", op.method, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+type param struct {
+	*Parameter
+	goName  string
+	argName string
+	goType  string
+}
+
+func (g *generator) genOperation(path, method string, item *PathItem, op *Operation) error {
+	name := exportName(op.OperationID)
+	if op.OperationID == "" {
+		name = exportName(strings.ToLower(method) + " " + path)
+	}
+
+	params, err := g.operationParams(item, op)
+	if err != nil {
+		return err
+	}
+	var pathParams, otherParams []param
+	for _, p := range params {
+		if p.In == "path" {
+			pathParams = append(pathParams, p)
+		} else if p.In == "query" || p.In == "header" {
+			otherParams = append(otherParams, p)
+		}
+	}
+
+	paramsType := name + "Params"
+	if len(otherParams) > 0 {
+		g.printf("type %s struct {\n", paramsType)
+		for _, p := range otherParams {
+			if p.Description != "" {
+				g.comment(p.Description)
+			}
+			g.printf("\t%s %s\n", p.goName, p.fieldType())
+		}
+		g.printf("}\n\n")
+	}
+
+	bodyType, bodyRequired, err := g.requestBodyType(op)
+	if err != nil {
+		return err
+	}
+	resultType, err := g.responseType(op)
+	if err != nil {
+		return err
+	}
+
+	g.use("context")
+	args := []string{"ctx context.Context"}
+	for _, p := range pathParams {
+		args = append(args, p.argName+" "+p.goType)
+	}
+	if len(otherParams) > 0 {
+		args = append(args, "params *"+paramsType)
+	}
+	if bodyType != "" {
+		if bodyRequired {
+			args = append(args, "body "+bodyType)
+		} else {
+			args = append(args, "body *"+bodyType)
+		}
+	}
+
+	returnType, zero, ptr := "error", "", false
+	if resultType != "" {
+		ptr = strings.HasPrefix(resultType, "struct") || g.isStruct(resultType)
+		if ptr {
+			returnType, zero = fmt.Sprintf("(*%s, error)", resultType), "nil"
+		} else {
+			returnType, zero = fmt.Sprintf("(%s, error)", resultType), zeroValue(resultType)
+		}
+	}
+	returnErr := "return err"
+	if zero != "" {
+		returnErr = "return " + zero + ", err"
+	}
+
+	if op.Summary != "" {
+		g.comment(name + " " + sentenceCase(strings.TrimSuffix(op.Summary, ".")) + ".")
+	} else {
+		g.printf("// %s calls %s %s.\n", name, method, path)
+	}
+	if op.Description != "" {
+		g.printf("//\n")
+		g.comment(op.Description)
+	}
+	g.printf("func (c *Client) %s(%s) %s {\n", name, strings.Join(args, ", "), returnType)
+	g.printf("\treq := c.client.Verb(%q).AbsPath().Suffix(%q)\n", method, path)
+	for _, p := range pathParams {
+		g.printf("\treq.PathParam(%q, %s)\n", p.Name, g.formatValue(p.goType, p.argName))
+	}
+	if len(otherParams) > 0 {
+		g.printf("\tif params != nil {\n")
+		for _, p := range otherParams {
+			g.genParam(p)
+		}
+		g.printf("\t}\n")
+	}
+	if bodyType != "" {
+		if !bodyRequired {
+			g.printf("\tif body != nil {\n")
+		}
+		g.use("encoding/json")
+		g.printf("\tdata, err := json.Marshal(body)\n\tif err != nil {\n\t\t%s\n\t}\n", returnErr)
+		g.printf("\treq.SetHeader(\"Content-Type\", \"application/json\").Body(data)\n")
+		if !bodyRequired {
+			g.printf("\t}\n")
+		}
+	}
+
+	if resultType == "" {
+		g.printf("\tif _, err := c.do(ctx, req); err != nil {\n\t\treturn err\n\t}\n\treturn nil\n}\n\n")
+		return nil
+	}
+	// A 204 or any other empty body returns the zero value rather than
+	// failing to decode.
+	g.use("encoding/json")
+	g.printf("\traw, err := c.do(ctx, req)\n\tif err != nil {\n\t\t%s\n\t}\n", returnErr)
+	g.printf("\tif len(raw) == 0 {\n\t\treturn %s, nil\n\t}\n", zero)
+	g.printf("\tvar out %s\n", resultType)
+	g.printf("\tif err := json.Unmarshal(raw, &out); err != nil {\n\t\t%s\n\t}\n", returnErr)
+	if ptr {
+		g.printf("\treturn &out, nil\n}\n\n")
+	} else {
+		g.printf("\treturn out, nil\n}\n\n")
+	}
+	return nil
+}
+
+func (g *generator) genParam(p param) {
+	set := func(value string) string {
+		if p.In == "header" {
+			return fmt.Sprintf("req.SetHeader(%q, %s)", p.Name, value)
+		}
+		return fmt.Sprintf("req.Param(%q, %s)", p.Name, value)
+	}
+	field := "params." + p.goName
+
+	switch {
+	case strings.HasPrefix(p.goType, "[]"):
+		g.printf("\t\tfor _, v := range %s {\n\t\t\t%s\n\t\t}\n", field, set(g.formatValue(strings.TrimPrefix(p.goType, "[]"), "v")))
+	case p.Required:
+		g.printf("\t\t%s\n", set(g.formatValue(p.goType, field)))
+	default:
+		g.printf("\t\tif %s != nil {\n\t\t\t%s\n\t\t}\n", field, set(g.formatValue(p.goType, "*"+field)))
+	}
+}
+
+func (p param) fieldType() string {
+	if p.Required || strings.HasPrefix(p.goType, "[]") {
+		return p.goType
+	}
+	return "*" + p.goType
+}
+
+// operationParams merges path-level and operation-level parameters, the
+// latter overriding the former.
+func (g *generator) operationParams(item *PathItem, op *Operation) ([]param, error) {
+	var merged []*Parameter
+	index := map[string]int{}
+	for _, list := range [][]*Parameter{item.Parameters, op.Parameters} {
+		for _, raw := range list {
+			p, err := g.doc.resolveParameter(raw)
+			if err != nil {
+				return nil, err
+			}
+			key := p.In + "/" + p.Name
+			if i, ok := index[key]; ok {
+				merged[i] = p
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, p)
+		}
+	}
+
+	params := make([]param, 0, len(merged))
+	used := map[string]bool{"ctx": true, "params": true, "body": true, "req": true, "data": true, "err": true, "out": true, "raw": true, "c": true}
+	for _, p := range merged {
+		goType, err := g.goType(p.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", p.Name, err)
+		}
+		argName := lowerFirst(exportName(p.Name))
+		if token.IsKeyword(argName) || used[argName] {
+			argName += "Param"
+		}
+		used[argName] = true
+		params = append(params, param{
+			Parameter: p,
+			goName:    exportName(p.Name),
+			argName:   argName,
+			goType:    goType,
+		})
+	}
+	return params, nil
+}
+
+func (g *generator) requestBodyType(op *Operation) (string, bool, error) {
+	if op.RequestBody == nil {
+		return "", false, nil
+	}
+	body, err := g.doc.resolveRequestBody(op.RequestBody)
+	if err != nil {
+		return "", false, err
+	}
+	media := jsonMedia(body.Content)
+	if media == nil {
+		return "", false, fmt.Errorf("request body has no JSON content")
+	}
+	goType, err := g.goType(media.Schema)
+	return goType, body.Required, err
+}
+
+// responseType returns the Go type of the first 2xx JSON response, or ""
+// when the operation returns no body.
+func (g *generator) responseType(op *Operation) (string, error) {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		resp, err := g.doc.resolveResponse(op.Responses[code])
+		if err != nil {
+			return "", err
+		}
+		if media := jsonMedia(resp.Content); media != nil && media.Schema != nil {
+			return g.goType(media.Schema)
+		}
+	}
+	return "", nil
+}
+
+func jsonMedia(content map[string]*MediaType) *MediaType {
+	if media, ok := content["application/json"]; ok {
+		return media
+	}
+	for contentType, media := range content {
+		if strings.HasSuffix(contentType, "+json") {
+			return media
+		}
+	}
+	return nil
+}
+
+func (g *generator) goType(s *Schema) (string, error) {
+	if s == nil {
+		return "interface{}", nil
+	}
+	if s.Ref != "" {
+		name, err := refName(s.Ref, "schemas")
+		if err != nil {
+			return "", err
+		}
+		if _, ok := g.doc.Components.Schemas[name]; !ok {
+			return "", fmt.Errorf("unknown schema %q", s.Ref)
+		}
+		return exportName(name), nil
+	}
+	if len(s.AllOf) > 0 || len(s.Properties) > 0 {
+		return g.structType(s)
+	}
+
+	switch s.Type.Name {
+	case "string":
+		if s.Format == "binary" || s.Format == "byte" {
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		item, err := g.goType(s.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	case "object", "":
+		additional, err := s.additionalSchema()
+		if err != nil {
+			return "", err
+		}
+		if additional != nil {
+			value, err := g.goType(additional)
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		if s.Type.Name == "object" {
+			return "map[string]interface{}", nil
+		}
+		return "interface{}", nil
+	}
+	return "", fmt.Errorf("unsupported type %q", s.Type.Name)
+}
+
+// structType renders an object schema. allOf references become embedded
+// structs, which encoding/json flattens back into one object.
+func (g *generator) structType(s *Schema) (string, error) {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+
+	var own []*Schema
+	for _, part := range s.AllOf {
+		if part.Ref != "" {
+			embedded, err := g.goType(part)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(embedded + "\n")
+			continue
+		}
+		own = append(own, part)
+	}
+	own = append(own, s)
+
+	for _, part := range own {
+		names := make([]string, 0, len(part.Properties))
+		for name := range part.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property := part.Properties[name]
+			goType, err := g.goType(property)
+			if err != nil {
+				return "", fmt.Errorf("property %s: %w", name, err)
+			}
+			tag := name
+			required := part.isRequired(name) || s.isRequired(name)
+			if !required || property.isNullable() {
+				if !strings.HasPrefix(goType, "[]") && !strings.HasPrefix(goType, "map[") && goType != "interface{}" {
+					goType = "*" + goType
+				}
+				tag += ",omitempty"
+			}
+			if property.Description != "" {
+				for _, line := range strings.Split(strings.TrimSpace(property.Description), "\n") {
+					b.WriteString("// " + strings.TrimSpace(line) + "\n")
+				}
+			}
+			fmt.Fprintf(&b, "%s %s `json:%s`\n", exportName(name), goType, strconv.Quote(tag))
+		}
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// isStruct reports whether typeName names a component schema rendered as
+// a struct.
+func (g *generator) isStruct(typeName string) bool {
+	for name, schema := range g.doc.Components.Schemas {
+		if exportName(name) == typeName {
+			return len(schema.AllOf) > 0 || len(schema.Properties) > 0
+		}
+	}
+	return false
+}
+
+func (g *generator) formatValue(goType, expr string) string {
+	if goType == "string" {
+		return expr
+	}
+	g.use("fmt")
+	return "fmt.Sprint(" + expr + ")"
+}
+
+func zeroValue(goType string) string {
+	switch goType {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "int32", "int64", "float32", "float64":
+		return "0"
+	}
+	return "nil"
+}
+
+var initialisms = map[string]string{
+	"id": "ID", "url": "URL", "uri": "URI", "http": "HTTP", "https": "HTTPS",
+	"api": "API", "json": "JSON", "xml": "XML", "uuid": "UUID", "ip": "IP",
+	"sql": "SQL", "tls": "TLS", "ttl": "TTL", "html": "HTML", "css": "CSS",
+}
+
+// exportName turns an OpenAPI name such as "pet_id", "pet-id" or "petId"
+// into an exported Go identifier, honoring common initialisms.
+func exportName(s string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	name := b.String()
+	if name == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// sentenceCase lowercases the first letter of a summary unless it starts
+// an acronym.
+func sentenceCase(s string) string {
+	r := []rune(s)
+	if len(r) > 1 && unicode.IsUpper(r[0]) && unicode.IsLower(r[1]) {
+		r[0] = unicode.ToLower(r[0])
+	}
+	return string(r)
+}
+
+// lowerFirst lowercases the leading initialism or letter of an exported
+// name: "PetID" becomes "petID", "ID" becomes "id", "URLPath" becomes
+// "urlPath".
+func lowerFirst(s string) string {
+	r := []rune(s)
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	switch {
+	case i == 0:
+		return s
+	case i == 1 || i == len(r):
+		return strings.ToLower(string(r[:i])) + string(r[i:])
+	default:
+		return strings.ToLower(string(r[:i-1])) + string(r[i-1:])
+	}
+}
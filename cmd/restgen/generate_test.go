@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Generate(t *testing.T) {
+	doc, err := LoadDocument("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("load document error: %s", err.Error())
+	}
+	code, err := Generate(doc, "petstore")
+	if err != nil {
+		t.Fatalf("generate error: %s", err.Error())
+	}
+
+	for _, want := range []string{
+		"package petstore",
+		"type Pet struct {\n\tNewPet\n\tID int64 `json:\"id\"`\n}",
+		`StatusAvailable Status = "available"`,
+		"func (c *Client) ListPets(ctx context.Context, params *ListPetsParams) ([]Pet, error) {",
+		`req.SetHeader("X-Request-Source", *params.XRequestSource)`,
+		"func (c *Client) CreatePet(ctx context.Context, body NewPet) (*Pet, error) {",
+		"func (c *Client) GetPet(ctx context.Context, petID int64) (*Pet, error) {",
+		`req := c.client.Verb("GET").AbsPath().Suffix("/pets/{petId}")`,
+		"\tif len(raw) == 0 {\n\t\treturn nil, nil\n\t}",
+		`req.PathParam("petId", fmt.Sprint(petID))`,
+		"func (c *Client) DeletePetsPetID(ctx context.Context, petID int64) error {",
+	} {
+		if !strings.Contains(string(code), want) {
+			t.Fatalf("generated code missing %q:\n%s", want, code)
+		}
+	}
+}
+
+// Test_GenerateCheckedIn keeps internal/petstore, which compiles and
+// exercises the generated code, in sync with the generator.
+func Test_GenerateCheckedIn(t *testing.T) {
+	doc, err := LoadDocument("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("load document error: %s", err.Error())
+	}
+	code, err := Generate(doc, "petstore")
+	if err != nil {
+		t.Fatalf("generate error: %s", err.Error())
+	}
+	checkedIn, err := os.ReadFile("internal/petstore/client.go")
+	if err != nil {
+		t.Fatalf("read checked in code error: %s", err.Error())
+	}
+	if !bytes.Equal(code, checkedIn) {
+		t.Fatalf("internal/petstore/client.go is stale, run go generate ./cmd/restgen/...")
+	}
+}
+
+func Test_GenerateImports(t *testing.T) {
+	doc, err := ParseDocument([]byte(`
+openapi: 3.0.3
+info: {title: Notes, version: 1.0.0}
+paths:
+  /notes/{name}:
+    delete:
+      operationId: deleteNote
+      summary: Uses json.Marshal and fmt.Sprint in its prose only.
+      description: See encoding/json. and fmt.Errorf.
+      parameters:
+        - {name: name, in: path, required: true, schema: {type: string}}
+      responses:
+        '204': {description: Deleted.}
+`), ".yaml")
+	if err != nil {
+		t.Fatalf("parse document error: %s", err.Error())
+	}
+	code, err := Generate(doc, "notes")
+	if err != nil {
+		t.Fatalf("generate error: %s", err.Error())
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "notes.go", code, parser.AllErrors)
+	if err != nil {
+		t.Fatalf("parse generated code error: %s", err.Error())
+	}
+	var imports []string
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		imports = append(imports, path)
+	}
+	if got := strings.Join(imports, " "); got != "context errors github.com/f0resee/stdlib/rest" {
+		t.Fatalf("imports = %s, want only context, errors and rest", got)
+	}
+}
+
+func Test_ExportName(t *testing.T) {
+	for in, want := range map[string]string{
+		"petId":        "PetID",
+		"pet_id":       "PetID",
+		"X-Request-ID": "XRequestID",
+		"HTTPServer":   "HTTPServer",
+		"get /pets":    "GetPets",
+		"2fa":          "X2fa",
+	} {
+		if got := exportName(in); got != want {
+			t.Fatalf("exportName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
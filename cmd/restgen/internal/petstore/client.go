@@ -0,0 +1,138 @@
+// Code generated by restgen. DO NOT EDIT.
+
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+type NewPet struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Name   string            `json:"name"`
+	Status *Status           `json:"status,omitempty"`
+	Tag    *string           `json:"tag,omitempty"`
+}
+
+// A pet in the store.
+type Pet struct {
+	NewPet
+	ID int64 `json:"id"`
+}
+
+type Status string
+
+const (
+	StatusAvailable Status = "available"
+	StatusSold      Status = "sold"
+)
+
+// Client is a typed client built on rest.RESTClient. Responses with a
+// non-2xx status are returned as *rest.StatusError. An empty response body,
+// as sent with 204 No Content, yields the zero value of the result type.
+type Client struct {
+	client *rest.RESTClient
+}
+
+func NewClient(client *rest.RESTClient) *Client {
+	return &Client{client: client}
+}
+
+// do sends req and returns the response body. rest.Request only treats
+// 200 OK as success; the other 2xx statuses are successes here too.
+func (c *Client) do(ctx context.Context, req *rest.Request) ([]byte, error) {
+	raw, err := req.Do(ctx).Raw()
+	var statusErr *rest.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= 200 && statusErr.StatusCode < 300 {
+		return raw, nil
+	}
+	return raw, err
+}
+
+type ListPetsParams struct {
+	Limit          *int32
+	Tag            []string
+	XRequestSource *string
+}
+
+// ListPets list all pets.
+func (c *Client) ListPets(ctx context.Context, params *ListPetsParams) ([]Pet, error) {
+	req := c.client.Verb("GET").AbsPath().Suffix("/pets")
+	if params != nil {
+		if params.Limit != nil {
+			req.Param("limit", fmt.Sprint(*params.Limit))
+		}
+		for _, v := range params.Tag {
+			req.Param("tag", v)
+		}
+		if params.XRequestSource != nil {
+			req.SetHeader("X-Request-Source", *params.XRequestSource)
+		}
+	}
+	raw, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var out []Pet
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatePet create a pet.
+func (c *Client) CreatePet(ctx context.Context, body NewPet) (*Pet, error) {
+	req := c.client.Verb("POST").AbsPath().Suffix("/pets")
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetHeader("Content-Type", "application/json").Body(data)
+	raw, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var out Pet
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetPet info for a specific pet.
+func (c *Client) GetPet(ctx context.Context, petID int64) (*Pet, error) {
+	req := c.client.Verb("GET").AbsPath().Suffix("/pets/{petId}")
+	req.PathParam("petId", fmt.Sprint(petID))
+	raw, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var out Pet
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeletePetsPetID calls DELETE /pets/{petId}.
+func (c *Client) DeletePetsPetID(ctx context.Context, petID int64) error {
+	req := c.client.Verb("DELETE").AbsPath().Suffix("/pets/{petId}")
+	req.PathParam("petId", fmt.Sprint(petID))
+	if _, err := c.do(ctx, req); err != nil {
+		return err
+	}
+	return nil
+}
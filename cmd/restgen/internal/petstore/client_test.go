@@ -0,0 +1,76 @@
+package petstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+func Test_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /pets/7":
+			w.Write([]byte(`{"id":7,"name":"rex","status":"sold"}`))
+		case "POST /pets":
+			var pet Pet
+			json.NewDecoder(r.Body).Decode(&pet.NewPet)
+			pet.ID = 8
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(pet)
+		case "GET /pets":
+			if r.URL.Query().Get("limit") != "2" || r.Header.Get("X-Request-Source") != "test" {
+				t.Errorf("unexpected list request %s %v", r.URL, r.Header)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "DELETE /pets/7":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := rest.NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	client := NewClient(restClient)
+	ctx := context.Background()
+
+	pet, err := client.GetPet(ctx, 7)
+	if err != nil {
+		t.Fatalf("get pet error: %s", err.Error())
+	}
+	if pet.ID != 7 || pet.Name != "rex" || pet.Status == nil || *pet.Status != StatusSold {
+		t.Fatalf("unexpected pet %+v", pet)
+	}
+
+	created, err := client.CreatePet(ctx, NewPet{Name: "fido"})
+	if err != nil {
+		t.Fatalf("create pet error: %s", err.Error())
+	}
+	if created.ID != 8 || created.Name != "fido" {
+		t.Fatalf("unexpected created pet %+v", created)
+	}
+
+	limit, source := int32(2), "test"
+	pets, err := client.ListPets(ctx, &ListPetsParams{Limit: &limit, XRequestSource: &source})
+	if err != nil || pets != nil {
+		t.Fatalf("list pets with no content = %v, %v", pets, err)
+	}
+
+	if err := client.DeletePetsPetID(ctx, 7); err != nil {
+		t.Fatalf("delete pet error: %s", err.Error())
+	}
+	var statusErr *rest.StatusError
+	if _, err := client.GetPet(ctx, 9); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("get missing pet = %v, want 404", err)
+	}
+}
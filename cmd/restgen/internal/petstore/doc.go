@@ -0,0 +1,5 @@
+// Package petstore is the restgen output for testdata/petstore.yaml. It is
+// checked in so the generated code is compiled and exercised by tests.
+package petstore
+
+//go:generate go run ../.. -in ../../testdata/petstore.yaml -out client.go -package petstore
@@ -0,0 +1,56 @@
+// Command restgen generates Go types and a typed rest.RESTClient wrapper
+// from an OpenAPI 3 document.
+//
+//	restgen -in petstore.yaml -out petstore/client.go -package petstore
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	in := flag.String("in", "", "OpenAPI 3 document, JSON or YAML")
+	out := flag.String("out", "", "output Go file, stdout when empty")
+	pkg := flag.String("package", "", "package name of the generated code, defaults to the output directory name")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkg == "" {
+		*pkg = "client"
+		if *out != "" {
+			if abs, err := filepath.Abs(*out); err == nil {
+				*pkg = filepath.Base(filepath.Dir(abs))
+			}
+		}
+	}
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintf(os.Stderr, "restgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg string) error {
+	doc, err := LoadDocument(in)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", in, err)
+	}
+	code, err := Generate(doc, pkg)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(out, code, 0644)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// The types below cover the part of OpenAPI 3 the generator understands.
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas"`
+	Parameters    map[string]*Parameter   `json:"parameters"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies"`
+	Responses     map[string]*Response    `json:"responses"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Delete     *Operation   `json:"delete"`
+	Options    *Operation   `json:"options"`
+	Head       *Operation   `json:"head"`
+	Patch      *Operation   `json:"patch"`
+}
+
+func (p *PathItem) operations() []struct {
+	method    string
+	operation *Operation
+} {
+	all := []struct {
+		method    string
+		operation *Operation
+	}{
+		{"GET", p.Get}, {"PUT", p.Put}, {"POST", p.Post}, {"DELETE", p.Delete},
+		{"OPTIONS", p.Options}, {"HEAD", p.Head}, {"PATCH", p.Patch},
+	}
+	result := all[:0]
+	for _, op := range all {
+		if op.operation != nil {
+			result = append(result, op)
+		}
+	}
+	return result
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref"`
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Ref     string                `json:"$ref"`
+	Content map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 SchemaType         `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []interface{}      `json:"enum"`
+	Items                *Schema            `json:"items"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	AllOf                []*Schema          `json:"allOf"`
+}
+
+// SchemaType accepts both the 3.0 string form and the 3.1 list form of
+// "type", where "null" marks the schema nullable.
+type SchemaType struct {
+	Name     string
+	Nullable bool
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		t.Name = name
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == "null" {
+			t.Nullable = true
+		} else if t.Name == "" {
+			t.Name = name
+		}
+	}
+	return nil
+}
+
+func (s *Schema) isNullable() bool {
+	return s.Nullable || s.Type.Nullable
+}
+
+func (s *Schema) isRequired(property string) bool {
+	for _, name := range s.Required {
+		if name == property {
+			return true
+		}
+	}
+	return false
+}
+
+// additionalSchema returns the schema of map values, or nil when the
+// schema does not describe a map.
+func (s *Schema) additionalSchema() (*Schema, error) {
+	if len(s.AdditionalProperties) == 0 || string(s.AdditionalProperties) == "false" {
+		return nil, nil
+	}
+	if string(s.AdditionalProperties) == "true" {
+		return &Schema{}, nil
+	}
+	var schema Schema
+	if err := json.Unmarshal(s.AdditionalProperties, &schema); err != nil {
+		return nil, fmt.Errorf("additionalProperties: %w", err)
+	}
+	return &schema, nil
+}
+
+// LoadDocument reads a JSON or YAML OpenAPI document.
+func LoadDocument(filename string) (*Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDocument(data, filepath.Ext(filename))
+}
+
+func ParseDocument(data []byte, ext string) (*Document, error) {
+	if ext == ".yaml" || ext == ".yml" {
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", doc.OpenAPI)
+	}
+	return &doc, nil
+}
+
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q", ref)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (d *Document) resolveParameter(p *Parameter) (*Parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown parameter %q", p.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *Document) resolveRequestBody(b *RequestBody) (*RequestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown request body %q", b.Ref)
+	}
+	return resolved, nil
+}
+
+func (d *Document) resolveResponse(r *Response) (*Response, error) {
+	if r.Ref == "" {
+		return r, nil
+	}
+	name, err := refName(r.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := d.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown response %q", r.Ref)
+	}
+	return resolved, nil
+}
@@ -0,0 +1,290 @@
+package logs
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy decides what an AsyncWriter or an async Logger does when
+// its buffer is full.
+type OverflowPolicy int8
+
+const (
+	// OverflowBlock makes Write wait for space.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the entry being written.
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest buffered entry.
+	OverflowDropOldest
+)
+
+const defaultAsyncBufferSize = 1024
+
+type AsyncConfig struct {
+	BufferSize int // entries, defaults to 1024
+	Policy     OverflowPolicy
+}
+
+// queue is a bounded ring of items handed in batches to a background
+// goroutine.
+type queue[T any] struct {
+	policy OverflowPolicy
+	handle func([]T)
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	drained  *sync.Cond
+	ring     []T
+	head     int
+	count    int
+	busy     bool // a batch is being handled
+	closed   bool
+
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+func newQueue[T any](config AsyncConfig, handle func([]T)) *queue[T] {
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultAsyncBufferSize
+	}
+	q := &queue[T]{
+		policy: config.Policy,
+		handle: handle,
+		ring:   make([]T, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	q.notEmpty = sync.NewCond(&q.mu)
+	q.notFull = sync.NewCond(&q.mu)
+	q.drained = sync.NewCond(&q.mu)
+	go q.run()
+	return q
+}
+
+// push queues v, applying the overflow policy. It only fails once the
+// queue is closed.
+func (q *queue[T]) push(v T) error {
+	var zero T
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for !q.closed && q.count == len(q.ring) {
+		switch q.policy {
+		case OverflowDropNewest:
+			q.dropped.Add(1)
+			return nil
+		case OverflowDropOldest:
+			q.ring[q.head] = zero
+			q.head = (q.head + 1) % len(q.ring)
+			q.count--
+			q.dropped.Add(1)
+		default:
+			q.notFull.Wait()
+		}
+	}
+	if q.closed {
+		return os.ErrClosed
+	}
+	q.ring[(q.head+q.count)%len(q.ring)] = v
+	q.count++
+	q.notEmpty.Signal()
+	return nil
+}
+
+// wait blocks until every queued item is handled.
+func (q *queue[T]) wait() {
+	q.mu.Lock()
+	for q.count > 0 || q.busy {
+		q.drained.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// close stops accepting items and waits for the queued ones to be handled.
+// It reports whether q was still open.
+func (q *queue[T]) close() bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		<-q.done
+		return false
+	}
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+	q.mu.Unlock()
+	<-q.done
+	return true
+}
+
+func (q *queue[T]) run() {
+	defer close(q.done)
+	var zero T
+	batch := make([]T, 0, len(q.ring))
+	q.mu.Lock()
+	for {
+		for q.count == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+		if q.count == 0 {
+			q.mu.Unlock()
+			return
+		}
+		for ; q.count > 0; q.count-- {
+			batch = append(batch, q.ring[q.head])
+			q.ring[q.head] = zero
+			q.head = (q.head + 1) % len(q.ring)
+		}
+		q.busy = true
+		q.notFull.Broadcast()
+		q.mu.Unlock()
+
+		q.handle(batch)
+		clear(batch)
+		batch = batch[:0]
+
+		q.mu.Lock()
+		q.busy = false
+		q.drained.Broadcast()
+	}
+}
+
+// AsyncWriter buffers writes in a bounded ring and writes them to the
+// underlying writer from a background goroutine. Each Write is one entry.
+// Entries are still formatted by the logging goroutine; Logger.SetAsync
+// moves the formatting to the background as well.
+type AsyncWriter struct {
+	w io.Writer
+	q *queue[[]byte]
+
+	errMu sync.Mutex
+	err   error
+}
+
+func NewAsyncWriter(w io.Writer, config AsyncConfig) *AsyncWriter {
+	a := &AsyncWriter{w: w}
+	a.q = newQueue(config, a.writeBatch)
+	return a
+}
+
+// Write queues a copy of p. It only fails once the writer is closed.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	if err := a.q.push(append([]byte(nil), p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (a *AsyncWriter) writeBatch(batch [][]byte) {
+	var err error
+	for _, p := range batch {
+		if _, werr := a.w.Write(p); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if err != nil {
+		a.errMu.Lock()
+		if a.err == nil {
+			a.err = err
+		}
+		a.errMu.Unlock()
+	}
+}
+
+// Dropped returns how many entries were discarded because the buffer was
+// full.
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.q.dropped.Load()
+}
+
+// Flush waits until every queued entry is written and returns the first
+// write error since the previous Flush.
+func (a *AsyncWriter) Flush() error {
+	a.q.wait()
+	a.errMu.Lock()
+	defer a.errMu.Unlock()
+	err := a.err
+	a.err = nil
+	return err
+}
+
+// Close flushes the buffer, stops the background goroutine and closes the
+// underlying writer if it is an io.Closer.
+func (a *AsyncWriter) Close() error {
+	if !a.q.close() {
+		return nil
+	}
+	err := a.Flush()
+	if c, ok := a.w.(io.Closer); ok {
+		if closeErr := c.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// SetAsync makes l and the loggers sharing its sinks queue entries and
+// format and write them from a background goroutine, so a log call only
+// builds the entry. Field values are formatted later and must not be
+// changed after they are logged. A Logger made by NewFromHandler still
+// passes entries to its handler synchronously.
+func (l *Logger) SetAsync(config AsyncConfig) {
+	set := l.sinks
+	l.swapAsync(newQueue(config, func(batch []*Entry) {
+		for _, e := range batch {
+			set.emit(e)
+		}
+	}))
+}
+
+// DisableAsync writes out the queued entries and makes logging synchronous
+// again.
+func (l *Logger) DisableAsync() {
+	l.swapAsync(nil)
+}
+
+func (l *Logger) swapAsync(q *queue[*Entry]) {
+	if old := l.sinks.async.Swap(q); old != nil {
+		old.close()
+	}
+}
+
+// Dropped returns how many entries the async queue of l discarded because
+// it was full.
+func (l *Logger) Dropped() uint64 {
+	if q := l.sinks.async.Load(); q != nil {
+		return q.dropped.Load()
+	}
+	return 0
+}
+
+// Close writes the summaries of pending dedupe intervals and the queued
+// entries, stops the async queue and closes
+// the writers the logger buffers through or opened: AsyncWriters and the
+// file set by SetOutputFile or SetRotatingOutputFile. Logging afterwards is
+// synchronous and fails on the closed writers.
+func (l *Logger) Close() error {
+	l.limiter.flush()
+	l.DisableAsync()
+	var errs []error
+	for _, s := range l.sinks.load() {
+		if a, ok := s.w.(*AsyncWriter); ok {
+			if err := a.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	l.sinks.mu.Lock()
+	owned := l.sinks.owned
+	l.sinks.owned = nil
+	l.sinks.mu.Unlock()
+	if owned != nil {
+		if err := owned.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,176 @@
+package logs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// gateWriter blocks every Write until the gate is opened.
+type gateWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func Test_AsyncWriter_DropOldest(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	a := NewAsyncWriter(w, AsyncConfig{BufferSize: 2, Policy: OverflowDropOldest})
+
+	// The first entry is taken by the background goroutine, which then
+	// blocks; the ring holds two more and drops the oldest of them.
+	a.Write([]byte("1\n"))
+	for {
+		a.q.mu.Lock()
+		busy := a.q.busy
+		a.q.mu.Unlock()
+		if busy {
+			break
+		}
+	}
+	for _, p := range []string{"2\n", "3\n", "4\n"} {
+		a.Write([]byte(p))
+	}
+	close(w.gate)
+	if err := a.Close(); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+	if got := w.String(); got != "1\n3\n4\n" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	if a.Dropped() != 1 {
+		t.Fatalf("dropped %d, want 1", a.Dropped())
+	}
+	if _, err := a.Write([]byte("late\n")); err == nil {
+		t.Fatalf("expected error after close")
+	}
+}
+
+func Test_AsyncWriter_DropNewest(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	a := NewAsyncWriter(w, AsyncConfig{BufferSize: 1, Policy: OverflowDropNewest})
+	a.q.mu.Lock()
+	a.q.ring[0], a.q.count = []byte("queued\n"), 1 // fill the ring before the writer runs
+	a.q.mu.Unlock()
+
+	a.Write([]byte("dropped\n"))
+	close(w.gate)
+	a.Close()
+	if a.Dropped() != 1 || strings.Contains(w.String(), "dropped") {
+		t.Fatalf("dropped %d, output %q", a.Dropped(), w.String())
+	}
+}
+
+func Test_Logger_FatalFlushes(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	l := New()
+	l.SetOutput(NewAsyncWriter(w, AsyncConfig{}))
+	for i := 0; i < 100; i++ {
+		l.Info("entry %d", i)
+	}
+	func() {
+		defer func() { recover() }()
+		l.Fatal("bye")
+	}()
+	if got := w.String(); strings.Count(got, "\n") != 101 || !strings.Contains(got, "bye") {
+		t.Fatalf("entries were not flushed before fatal: %q", got)
+	}
+}
+
+// gateFormatter blocks every Format until the gate is opened.
+type gateFormatter struct {
+	gate chan struct{}
+	TextFormatter
+}
+
+func (f *gateFormatter) Format(e *Entry) ([]byte, error) {
+	<-f.gate
+	return f.TextFormatter.Format(e)
+}
+
+func Test_Logger_Async(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	formatter := &gateFormatter{gate: make(chan struct{}), TextFormatter: TextFormatter{Color: ColorNever}}
+	l.SetFormatter(formatter)
+	l.SetAsync(AsyncConfig{BufferSize: 2, Policy: OverflowDropNewest})
+
+	// The first entry is taken by the background goroutine, which blocks
+	// in the formatter; the log calls return meanwhile.
+	l.Info("1")
+	for {
+		q := l.sinks.async.Load()
+		q.mu.Lock()
+		busy := q.busy
+		q.mu.Unlock()
+		if busy {
+			break
+		}
+	}
+	l.With("k", "v").Info("2")
+	l.Info("3")
+	l.Info("4")
+	close(formatter.gate)
+	if err := l.Flush(); err != nil {
+		t.Fatalf("flush error: %s", err.Error())
+	}
+	got := buf.String()
+	if strings.Count(got, "\n") != 3 || !strings.Contains(got, "2 k=v") || strings.Contains(got, "] 4\n") {
+		t.Fatalf("unexpected output %q", got)
+	}
+	if l.Dropped() != 1 {
+		t.Fatalf("dropped %d, want 1", l.Dropped())
+	}
+
+	l.DisableAsync()
+	l.Info("sync")
+	if !strings.HasSuffix(buf.String(), "sync\n") {
+		t.Fatalf("entry after DisableAsync was not written: %q", buf.String())
+	}
+}
+
+func Test_Logger_FatalExitCloses(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	osExit = func(int) {}
+
+	filename := filepath.Join(t.TempDir(), "app.log")
+	l := New()
+	if err := l.SetOutputFile(filename); err != nil {
+		t.Fatalf("set output error: %s", err.Error())
+	}
+	file := l.sinks.owned.(*os.File)
+	l.SetAsync(AsyncConfig{})
+	l.SetFatalPolicy(FatalExit)
+	for i := 0; i < 100; i++ {
+		l.Info("entry %d", i)
+	}
+	l.Fatal("bye")
+
+	data, _ := os.ReadFile(filename)
+	if strings.Count(string(data), "\n") != 101 || !strings.Contains(string(data), "bye") {
+		t.Fatalf("entries were not written before exit: %q", data)
+	}
+	if _, err := file.Write([]byte("x")); err == nil {
+		t.Fatalf("output file is still open after fatal")
+	}
+	if l.sinks.async.Load() != nil {
+		t.Fatalf("async queue still running after fatal")
+	}
+}
@@ -0,0 +1,77 @@
+package logs
+
+import (
+	"context"
+	"strings"
+)
+
+type fieldsKey struct{}
+type loggerKey struct{}
+
+// WithFields returns a context carrying keysAndValues in addition to the
+// fields already on ctx. Ctx* methods add them to every entry.
+func WithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	fields := contextFields(ctx)
+	return context.WithValue(ctx, fieldsKey{}, append(fields[:len(fields):len(fields)], toFields(keysAndValues)...))
+}
+
+func contextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// IntoContext returns a context carrying l, for FromContext.
+func IntoContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// FromContext returns the logger stored by IntoContext, or the default
+// logger set up to be called directly.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*Logger); ok {
+		return l
+	}
+	l := *Default()
+	l.callerDepth = 2
+	return &l
+}
+
+// TraceContext holds the fields of a W3C traceparent.
+type TraceContext struct {
+	Version string
+	TraceID string
+	SpanID  string // parent-id of the traceparent
+	Flags   string
+}
+
+// ParseTraceParent parses a W3C traceparent such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". The fields
+// are lowercase hex, version ff and all-zero IDs are invalid, and only
+// versions after 00 may append more fields.
+func ParseTraceParent(traceparent string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || parts[0] == "00" && len(parts) > 4 {
+		return TraceContext{}, false
+	}
+	tc := TraceContext{Version: parts[0], TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+	if !isLowerHex(tc.Version, 2) || tc.Version == "ff" || !isLowerHex(tc.TraceID, 32) ||
+		!isLowerHex(tc.SpanID, 16) || !isLowerHex(tc.Flags, 2) {
+		return TraceContext{}, false
+	}
+	if strings.Trim(tc.TraceID, "0") == "" || strings.Trim(tc.SpanID, "0") == "" {
+		return TraceContext{}, false
+	}
+	return tc, true
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
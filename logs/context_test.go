@@ -0,0 +1,86 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_WithFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&JSONFormatter{})
+
+	ctx := context.WithValue(context.Background(), RequestID{}, "req-1")
+	ctx = context.WithValue(ctx, TraceParent{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = WithFields(ctx, "user", "alice")
+	inner := WithFields(ctx, "attempt", 2)
+
+	l.With("component", "api").CtxInfow(inner, "handled", "status", 200)
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", buf.String(), err.Error())
+	}
+	want := map[string]interface{}{
+		"request_id": "req-1",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+		"component":  "api",
+		"user":       "alice",
+		"attempt":    float64(2),
+		"status":     float64(200),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("key %s: got %v, want %v", k, got[k], v)
+		}
+	}
+	if fields := contextFields(ctx); len(fields) != 1 {
+		t.Fatalf("parent context fields changed: %v", fields)
+	}
+
+	buf.Reset()
+	l.Info("no context")
+	if strings.Contains(buf.String(), "alice") {
+		t.Fatalf("non-context method logged context fields: %q", buf.String())
+	}
+}
+
+func Test_FromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf).Named("handler")
+	ctx := IntoContext(context.Background(), l)
+	if FromContext(ctx) != l {
+		t.Fatalf("FromContext did not return the stored logger")
+	}
+
+	FromContext(ctx).CtxInfo(ctx, "hello")
+	if !strings.Contains(buf.String(), "context_test.go:") || !strings.Contains(buf.String(), "handler hello") {
+		t.Fatalf("unexpected line %q", buf.String())
+	}
+	if FromContext(context.Background()).callerDepth != 2 {
+		t.Fatalf("fallback logger is not set up for direct calls")
+	}
+}
+
+func Test_ParseTraceParent(t *testing.T) {
+	tc, ok := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	if !ok || tc != (TraceContext{Version: "01", TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Flags: "01"}) {
+		t.Fatalf("ParseTraceParent = %+v, %v", tc, ok)
+	}
+	for _, traceparent := range []string{
+		"",
+		"00-xyz-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if tc, ok := ParseTraceParent(traceparent); ok {
+			t.Fatalf("accepted %q as %+v", traceparent, tc)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Entry is a single log record as handed to the output.
+type Entry struct {
+	Time      time.Time
+	Level     Level
+	Caller    string // file:line, empty when unknown
+	Function  string
+	Logger    string // name given by Named
+	Context   string // result of the context function, Ctx* methods only
+	RequestID string
+	TraceID   string
+	SpanID    string
+	Message   string
+	Fields    []Field
+	Stack     string // set at and above the stack trace level
+
+	ctx      context.Context
+	pc       uintptr
+	terminal bool // the output is a terminal
+}
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (lvl Level) String() string {
+	if lvl < LevelDebug || lvl > LevelFatal {
+		return fmt.Sprintf("LEVEL(%d)", lvl)
+	}
+	return levelNames[lvl]
+}
+
+// logf and logw are called directly by the exported methods, so the caller
+// of those is l.callerDepth frames above newEntry's caller.
+func (l *Logger) logf(ctx context.Context, level Level, format string, values []interface{}) {
+	l.write(l.newEntry(ctx, level, fmt.Sprintf(l.msgPrefix+format, values...), nil))
+}
+
+func (l *Logger) logw(ctx context.Context, level Level, msg string, keysAndValues []interface{}) {
+	l.write(l.newEntry(ctx, level, l.msgPrefix+msg, toFields(keysAndValues)))
+}
+
+func (l *Logger) newEntry(ctx context.Context, level Level, msg string, fields []Field) *Entry {
+	var pcs [1]uintptr
+	runtime.Callers(l.callerDepth+2, pcs[:])
+	e := l.newEntryAt(ctx, time.Now(), pcs[0], level, msg, fields)
+	if level >= l.stackLevel {
+		e.Stack = captureStack(l.callerDepth + 1)
+	}
+	return e
+}
+
+// newEntryAt builds an entry for a call site given by its return pc, as
+// reported by runtime.Callers. A zero pc leaves the caller empty.
+func (l *Logger) newEntryAt(ctx context.Context, t time.Time, pc uintptr, level Level, msg string, fields []Field) *Entry {
+	e := &Entry{
+		Time:    t,
+		Level:   level,
+		Message: msg,
+		Logger:  l.name,
+		ctx:     ctx,
+		pc:      pc,
+	}
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if frame.File != "" {
+			e.Caller = l.getFilePosition(frame.File, frame.Line)
+			e.Function = l.getCurrentFunctionName(frame.Function)
+		}
+	}
+	if ctx != nil {
+		if l.ctxFunc != nil {
+			e.Context = l.ctxFunc(ctx)
+		}
+		e.RequestID, _ = ctx.Value(RequestID{}).(string)
+		if traceparent, ok := ctx.Value(TraceParent{}).(string); ok {
+			if tc, ok := ParseTraceParent(traceparent); ok {
+				e.TraceID, e.SpanID = tc.TraceID, tc.SpanID
+			}
+		}
+	}
+	var ctxFields []Field
+	if ctx != nil {
+		ctxFields = contextFields(ctx)
+	}
+	if len(l.fields) > 0 || len(ctxFields) > 0 {
+		e.Fields = make([]Field, 0, len(l.fields)+len(ctxFields)+len(fields))
+		e.Fields = append(append(append(e.Fields, l.fields...), ctxFields...), fields...)
+	} else {
+		e.Fields = fields
+	}
+	e.Fields = expandErrors(e.Fields)
+	return e
+}
+
+func (l *Logger) write(e *Entry) {
+	if !l.limiter.allow(e, l.emit) {
+		return
+	}
+	l.emit(e)
+}
+
+func (l *Logger) emit(e *Entry) {
+	if l.handler != nil {
+		l.handle(e)
+		return
+	}
+	if q := l.sinks.async.Load(); q != nil && q.push(e) == nil {
+		return
+	}
+	l.sinks.emit(e)
+}
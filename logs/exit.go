@@ -0,0 +1,178 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// FatalPolicy decides how Fatal ends the program once the entry is written.
+type FatalPolicy int8
+
+const (
+	// FatalPanic panics, so deferred functions and recover still run.
+	FatalPanic FatalPolicy = iota
+	// FatalExit calls os.Exit with the configured exit code.
+	FatalExit
+)
+
+const defaultExitCode = 1
+
+// stackDisabled is above every level, so no stack is captured.
+const stackDisabled = LevelFatal + 1
+
+var (
+	exitHooksMu sync.Mutex
+	exitHooks   []func()
+
+	osExit = os.Exit
+)
+
+// RegisterExitHook adds f to the functions run by Fatal before buffered
+// sinks are flushed or closed, in registration order.
+func RegisterExitHook(f func()) {
+	exitHooksMu.Lock()
+	defer exitHooksMu.Unlock()
+	exitHooks = append(exitHooks, f)
+}
+
+func runExitHooks() {
+	exitHooksMu.Lock()
+	hooks := append([]func(){}, exitHooks...)
+	exitHooksMu.Unlock()
+	for _, hook := range hooks {
+		func() {
+			// A failing hook must not keep the others or the flush from running.
+			defer func() {
+				if r := recover(); r != nil {
+					fmt.Fprintf(os.Stderr, "logs: exit hook panicked: %v\n", r)
+				}
+			}()
+			hook()
+		}()
+	}
+}
+
+// SetFatalPolicy sets how Fatal ends the program, FatalPanic by default.
+func (l *Logger) SetFatalPolicy(policy FatalPolicy) {
+	l.fatalPolicy = policy
+}
+
+// SetExitCode sets the code FatalExit exits with, 1 by default.
+func (l *Logger) SetExitCode(code int) {
+	l.exitCode = code
+}
+
+// SetStackTraceLevel makes entries at level and above carry the stack of
+// the logging goroutine. It is off by default.
+func (l *Logger) SetStackTraceLevel(level Level) {
+	l.stackLevel = level
+}
+
+// DisableStackTrace turns stack capture off again.
+func (l *Logger) DisableStackTrace() {
+	l.stackLevel = stackDisabled
+}
+
+// fatal closes the logger before exiting. A panic may be recovered and
+// logging go on, so it only flushes then.
+func (l *Logger) fatal() {
+	runExitHooks()
+	if l.fatalPolicy == FatalExit {
+		l.Close()
+		osExit(l.exitCode)
+		return
+	}
+	l.Flush()
+	panic("panic happened because fatal is reported")
+}
+
+// captureStack formats the stack starting skip frames above its caller.
+func captureStack(skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+	return formatStack(pcs[:n])
+}
+
+// captureStackFrom formats the stack starting at the frame of the return pc,
+// as found in slog.Record.PC. When pc is not on the current stack, the
+// stack starts skip frames above captureStackFrom's caller instead.
+func captureStackFrom(pc uintptr, skip int) string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	for i, p := range pcs[:n] {
+		if p == pc {
+			return formatStack(pcs[i:n])
+		}
+	}
+	if skip > n {
+		skip = n
+	}
+	return formatStack(pcs[skip:n])
+}
+
+func formatStack(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// expandErrors adds a "<key>.causes" field after every error field whose
+// error wraps others, listing the messages of the wrapped errors.
+func expandErrors(fields []Field) []Field {
+	var expanded []Field
+	for i, f := range fields {
+		var causes []string
+		if err, ok := f.Value.(error); ok {
+			causes = errorCauses(err)
+		}
+		if len(causes) == 0 {
+			if expanded != nil {
+				expanded = append(expanded, f)
+			}
+			continue
+		}
+		if expanded == nil {
+			expanded = append(make([]Field, 0, len(fields)+1), fields[:i]...)
+		}
+		expanded = append(expanded, f, Field{Key: f.Key + ".causes", Value: causes})
+	}
+	if expanded == nil {
+		return fields
+	}
+	return expanded
+}
+
+// errorCauses lists the messages of the errors err wraps, depth first, as
+// errors.Is would visit them.
+func errorCauses(err error) []string {
+	var causes []string
+	var walk func(error)
+	walk = func(err error) {
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			if inner := x.Unwrap(); inner != nil {
+				causes = append(causes, inner.Error())
+				walk(inner)
+			}
+		case interface{ Unwrap() []error }:
+			for _, inner := range x.Unwrap() {
+				if inner != nil {
+					causes = append(causes, inner.Error())
+					walk(inner)
+				}
+			}
+		}
+	}
+	walk(err)
+	return causes
+}
@@ -0,0 +1,84 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func Test_Fatal_Exit(t *testing.T) {
+	defer func(exit func(int)) { osExit = exit }(osExit)
+	defer func(hooks []func()) { exitHooks = hooks }(exitHooks)
+
+	var calls []string
+	osExit = func(code int) { calls = append(calls, fmt.Sprintf("exit %d", code)) }
+	RegisterExitHook(func() { calls = append(calls, "hook 1") })
+	RegisterExitHook(func() { panic("broken hook") })
+	RegisterExitHook(func() { calls = append(calls, "hook 2") })
+
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	l := New()
+	l.SetOutput(NewAsyncWriter(w, AsyncConfig{}))
+	l.SetFatalPolicy(FatalExit)
+	l.SetExitCode(3)
+	l.Fatalw("cannot start", "port", 80)
+
+	if got := strings.Join(calls, ","); got != "hook 1,hook 2,exit 3" {
+		t.Fatalf("unexpected calls %s", got)
+	}
+	if !strings.Contains(w.String(), "cannot start port=80") {
+		t.Fatalf("fatal entry was not flushed: %q", w.String())
+	}
+}
+
+func Test_StackTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&JSONFormatter{})
+	l.SetStackTraceLevel(LevelError)
+
+	l.Warn("no stack")
+	l.Error("with stack")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	var warn, failure map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &warn)
+	json.Unmarshal([]byte(lines[1]), &failure)
+	if _, ok := warn["stack"]; ok {
+		t.Fatalf("warn entry has a stack: %v", warn)
+	}
+	stack, _ := failure["stack"].(string)
+	if !strings.HasPrefix(stack, "github.com/f0resee/stdlib/logs.Test_StackTrace\n\t") {
+		t.Fatalf("unexpected stack %q", stack)
+	}
+}
+
+func Test_ErrorCauses(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&JSONFormatter{})
+
+	err := fmt.Errorf("load config: %w", fmt.Errorf("open file: %w", fs.ErrNotExist))
+	l.Errorw("start failed", Err(err), "joined", errors.Join(errors.New("a"), errors.New("b")))
+
+	var got struct {
+		Error        string   `json:"error"`
+		ErrorCauses  []string `json:"error.causes"`
+		JoinedCauses []string `json:"joined.causes"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", buf.String(), err.Error())
+	}
+	if got.Error != err.Error() ||
+		strings.Join(got.ErrorCauses, "|") != "open file: file does not exist|file does not exist" ||
+		strings.Join(got.JoinedCauses, "|") != "a|b" {
+		t.Fatalf("unexpected entry %+v", got)
+	}
+}
@@ -0,0 +1,231 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Field is a typed key-value pair attached to an entry. Text output renders
+// it as key=value; the value keeps its type for structured encoders.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func String(key, value string) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Int(key string, value int) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Err(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+const badKey = "!BADKEY"
+
+// toFields converts alternating keys and values into fields. A Field may
+// also be passed in place of a pair. A non-string key or a trailing value
+// without a key is kept under "!BADKEY" rather than dropped.
+func toFields(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); {
+		switch key := keysAndValues[i].(type) {
+		case Field:
+			fields = append(fields, key)
+			i++
+		case string:
+			if i+1 == len(keysAndValues) {
+				fields = append(fields, Field{Key: badKey, Value: key})
+				i++
+				continue
+			}
+			fields = append(fields, Field{Key: key, Value: keysAndValues[i+1]})
+			i += 2
+		default:
+			fields = append(fields, Field{Key: badKey, Value: key})
+			i++
+		}
+	}
+	return fields
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case error:
+		return v.Error()
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []string:
+		return strings.Join(v, "; ")
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return r == '=' || r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// With returns a child logger that adds fields to every entry. The child
+// shares l's sinks and starts from a copy of its other settings.
+func (l *Logger) With(keysAndValues ...interface{}) *Logger {
+	child := *l
+	child.fields = append(l.fields[:len(l.fields):len(l.fields)], toFields(keysAndValues)...)
+	return &child
+}
+
+func (l *Logger) Debugw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelDebug) {
+		return
+	}
+	l.logw(nil, LevelDebug, msg, keysAndValues)
+}
+
+func (l *Logger) CtxDebugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelDebug) {
+		return
+	}
+	l.logw(ctx, LevelDebug, msg, keysAndValues)
+}
+
+func (l *Logger) Infow(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelInfo) {
+		return
+	}
+	l.logw(nil, LevelInfo, msg, keysAndValues)
+}
+
+func (l *Logger) CtxInfow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelInfo) {
+		return
+	}
+	l.logw(ctx, LevelInfo, msg, keysAndValues)
+}
+
+func (l *Logger) Warnw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelWarn) {
+		return
+	}
+	l.logw(nil, LevelWarn, msg, keysAndValues)
+}
+
+func (l *Logger) CtxWarnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelWarn) {
+		return
+	}
+	l.logw(ctx, LevelWarn, msg, keysAndValues)
+}
+
+func (l *Logger) Errorw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelError) {
+		return
+	}
+	l.logw(nil, LevelError, msg, keysAndValues)
+}
+
+func (l *Logger) CtxErrorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelError) {
+		return
+	}
+	l.logw(ctx, LevelError, msg, keysAndValues)
+}
+
+func (l *Logger) Fatalw(msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelFatal) {
+		return
+	}
+	l.logw(nil, LevelFatal, msg, keysAndValues)
+	l.fatal()
+}
+
+func (l *Logger) CtxFatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if !l.enabled(LevelFatal) {
+		return
+	}
+	l.logw(ctx, LevelFatal, msg, keysAndValues)
+	l.fatal()
+}
+
+// With returns a child of the default logger. Its caller depth already
+// accounts for the package-level wrappers, so use its methods directly.
+func With(keysAndValues ...interface{}) *Logger {
+	child := Default().With(keysAndValues...)
+	child.SetCallerDepth(2)
+	return child
+}
+
+func Debugw(msg string, keysAndValues ...interface{}) {
+	Default().Debugw(msg, keysAndValues...)
+}
+
+func CtxDebugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().CtxDebugw(ctx, msg, keysAndValues...)
+}
+
+func Infow(msg string, keysAndValues ...interface{}) {
+	Default().Infow(msg, keysAndValues...)
+}
+
+func CtxInfow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().CtxInfow(ctx, msg, keysAndValues...)
+}
+
+func Warnw(msg string, keysAndValues ...interface{}) {
+	Default().Warnw(msg, keysAndValues...)
+}
+
+func CtxWarnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().CtxWarnw(ctx, msg, keysAndValues...)
+}
+
+func Errorw(msg string, keysAndValues ...interface{}) {
+	Default().Errorw(msg, keysAndValues...)
+}
+
+func CtxErrorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().CtxErrorw(ctx, msg, keysAndValues...)
+}
+
+func Fatalw(msg string, keysAndValues ...interface{}) {
+	Default().Fatalw(msg, keysAndValues...)
+}
+
+func CtxFatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	Default().CtxFatalw(ctx, msg, keysAndValues...)
+}
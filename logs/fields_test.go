@@ -0,0 +1,66 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestLogger(buf *bytes.Buffer) *Logger {
+	l := New()
+	l.SetOutput(buf)
+	return l
+}
+
+func Test_Infow(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+
+	l.With("component", "sync").Infow("loaded", "count", 3, "name", "a b", Err(errors.New("boom")), "dangling")
+	line := buf.String()
+	for _, want := range []string{
+		"[INFO] ", "fields_test.go:", "[Test_Infow]",
+		`loaded component=sync count=3 name="a b" error=boom !BADKEY=dangling`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("line %q does not contain %q", line, want)
+		}
+	}
+}
+
+func Test_With(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	parent := l.With("a", 1)
+	_ = parent.With("b", 2)
+	child := parent.With("c", 3)
+
+	ctx := context.WithValue(context.Background(), RequestID{}, "req-1")
+	child.CtxWarnw(ctx, "done")
+	line := buf.String()
+	if !strings.Contains(line, "req-1 done a=1 c=3") {
+		t.Fatalf("unexpected line %q", line)
+	}
+
+	buf.Reset()
+	l.Infow("plain")
+	if strings.Contains(buf.String(), "a=1") {
+		t.Fatalf("parent got child fields: %q", buf.String())
+	}
+}
+
+func Test_Info_Format(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetLevel(LevelWarn)
+	l.Info("skipped %d", 1)
+	if buf.Len() != 0 {
+		t.Fatalf("info logged below level: %q", buf.String())
+	}
+	l.Warn("value %d", 2)
+	if !strings.Contains(buf.String(), "[WARN]  ") || !strings.Contains(buf.String(), "[Test_Info_Format] value 2") {
+		t.Fatalf("unexpected line %q", buf.String())
+	}
+}
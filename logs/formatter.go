@@ -0,0 +1,209 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Formatter turns an entry into one line of output, including the trailing
+// newline.
+type Formatter interface {
+	Format(e *Entry) ([]byte, error)
+}
+
+type ColorMode int8
+
+const (
+	// ColorAuto colors output only when it is written to a terminal.
+	ColorAuto ColorMode = iota
+	ColorAlways
+	ColorNever
+)
+
+const defaultTextTimeFormat = "2006-01-02 15:04:05.000"
+
+// TextFormatter writes the human readable format:
+//
+//	[INFO]  2006-01-02 15:04:05.000 main.go:12 [main] logger.name request-id message key=value
+type TextFormatter struct {
+	TimeFormat string         // defaults to "2006-01-02 15:04:05.000"
+	Location   *time.Location // defaults to time.Local
+	Color      ColorMode
+}
+
+func (f *TextFormatter) Format(e *Entry) ([]byte, error) {
+	var meta string
+	if e.Caller != "" {
+		meta = fmt.Sprintf("%v %v [%v]", formatTime(e.Time, f.TimeFormat, defaultTextTimeFormat, f.Location), e.Caller, e.Function)
+	}
+	var b strings.Builder
+	b.WriteString(e.Message)
+	if e.TraceID != "" {
+		b.WriteString(" trace_id=" + e.TraceID + " span_id=" + e.SpanID)
+	}
+	for _, field := range e.Fields {
+		b.WriteByte(' ')
+		writeLogfmtField(&b, field.Key, formatValue(field.Value))
+	}
+	line := stringify("["+e.Level.String()+"] ", meta, e.Logger, e.Context, b.String())
+	if (f.Color == ColorAlways || f.Color == ColorAuto && e.terminal) && e.Level >= LevelDebug && int(e.Level) < len(colorFormat) {
+		line = fmt.Sprintf(colorFormat[e.Level], line)
+	}
+	if e.Stack != "" {
+		line += "\n" + e.Stack
+	}
+	return []byte(line + "\n"), nil
+}
+
+// JSONFormatter writes one JSON object per entry. Custom fields are
+// top-level keys; those clashing with a built-in key get a "fields." prefix.
+type JSONFormatter struct {
+	TimeFormat string         // defaults to time.RFC3339Nano
+	Location   *time.Location // defaults to time.Local
+}
+
+func (f *JSONFormatter) Format(e *Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(&buf, key)
+		buf.WriteByte(':')
+	}
+	for _, kv := range builtinKeys(e, formatTime(e.Time, f.TimeFormat, time.RFC3339Nano, f.Location)) {
+		writeKey(kv[0])
+		writeJSONString(&buf, kv[1])
+	}
+	for _, field := range e.Fields {
+		key := field.Key
+		if isBuiltinKey(key) {
+			key = "fields." + key
+		}
+		writeKey(key)
+		writeJSONValue(&buf, field.Value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// LogfmtFormatter writes key=value pairs in the logfmt convention.
+type LogfmtFormatter struct {
+	TimeFormat string         // defaults to time.RFC3339Nano
+	Location   *time.Location // defaults to time.Local
+}
+
+func (f *LogfmtFormatter) Format(e *Entry) ([]byte, error) {
+	var b strings.Builder
+	for i, kv := range builtinKeys(e, formatTime(e.Time, f.TimeFormat, time.RFC3339Nano, f.Location)) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		writeLogfmtField(&b, kv[0], kv[1])
+	}
+	for _, field := range e.Fields {
+		key := field.Key
+		if isBuiltinKey(key) {
+			key = "fields." + key
+		}
+		b.WriteByte(' ')
+		writeLogfmtField(&b, key, formatValue(field.Value))
+	}
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+}
+
+var builtinKeyNames = []string{"time", "level", "caller", "function", "logger", "request_id", "trace_id", "span_id", "context", "msg", "stack"}
+
+func isBuiltinKey(key string) bool {
+	for _, name := range builtinKeyNames {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinKeys lists the non-empty built-in keys of e in output order. The
+// context function result is only added when it differs from the request ID.
+func builtinKeys(e *Entry, ts string) [][2]string {
+	kvs := [][2]string{{"time", ts}, {"level", strings.ToLower(e.Level.String())}}
+	if e.Caller != "" {
+		kvs = append(kvs, [2]string{"caller", e.Caller}, [2]string{"function", e.Function})
+	}
+	if e.Logger != "" {
+		kvs = append(kvs, [2]string{"logger", e.Logger})
+	}
+	if e.RequestID != "" {
+		kvs = append(kvs, [2]string{"request_id", e.RequestID})
+	}
+	if e.TraceID != "" {
+		kvs = append(kvs, [2]string{"trace_id", e.TraceID}, [2]string{"span_id", e.SpanID})
+	}
+	if e.Context != "" && e.Context != e.RequestID {
+		kvs = append(kvs, [2]string{"context", e.Context})
+	}
+	kvs = append(kvs, [2]string{"msg", e.Message})
+	if e.Stack != "" {
+		kvs = append(kvs, [2]string{"stack", e.Stack})
+	}
+	return kvs
+}
+
+func formatTime(t time.Time, layout, defaultLayout string, loc *time.Location) string {
+	if layout == "" {
+		layout = defaultLayout
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(layout)
+}
+
+func writeLogfmtField(b *strings.Builder, key, value string) {
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(quoteIfNeeded(value))
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
+
+// writeJSONValue keeps numbers, booleans and JSON-marshalable values typed.
+// Errors, durations and values that fail to marshal are written as strings.
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	switch v.(type) {
+	case error, time.Duration:
+		writeJSONString(buf, formatValue(v))
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		writeJSONString(buf, formatValue(v))
+		return
+	}
+	buf.Write(data)
+}
+
+func isTerminal(w io.Writer) bool {
+	if a, ok := w.(*AsyncWriter); ok {
+		w = a.w
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
@@ -0,0 +1,82 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntry() *Entry {
+	return &Entry{
+		Time:      time.Date(2024, 5, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600)),
+		Level:     LevelWarn,
+		Caller:    "main.go:12",
+		Function:  "main",
+		Context:   "req-1",
+		RequestID: "req-1",
+		Message:   "slow call",
+		Fields:    []Field{Int("attempt", 2), Duration("elapsed", 1500*time.Millisecond), Err(errors.New("timeout")), String("msg", "shadowed")},
+	}
+}
+
+func Test_JSONFormatter(t *testing.T) {
+	data, err := (&JSONFormatter{Location: time.UTC}).Format(testEntry())
+	if err != nil {
+		t.Fatalf("format error: %s", err.Error())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", data, err.Error())
+	}
+	want := map[string]interface{}{
+		"time":       "2024-05-01T00:30:00Z",
+		"level":      "warn",
+		"caller":     "main.go:12",
+		"function":   "main",
+		"request_id": "req-1",
+		"msg":        "slow call",
+		"attempt":    float64(2),
+		"elapsed":    "1.5s",
+		"error":      "timeout",
+		"fields.msg": "shadowed",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("key %s: got %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func Test_LogfmtFormatter(t *testing.T) {
+	data, err := (&LogfmtFormatter{TimeFormat: time.Kitchen}).Format(testEntry())
+	if err != nil {
+		t.Fatalf("format error: %s", err.Error())
+	}
+	want := `time=8:30AM level=warn caller=main.go:12 function=main request_id=req-1 msg="slow call" attempt=2 elapsed=1.5s error=timeout fields.msg=shadowed` + "\n"
+	if string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+}
+
+func Test_TextFormatter_Color(t *testing.T) {
+	e := testEntry()
+	data, _ := (&TextFormatter{Color: ColorAlways}).Format(e)
+	if !strings.HasPrefix(string(data), "\033[1;33m[WARN] ") {
+		t.Fatalf("expected colored line, got %q", data)
+	}
+
+	// A buffer is not a terminal, so the default formatter writes no color.
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.CtxWarnw(context.WithValue(context.Background(), RequestID{}, "req-1"), "plain")
+	if strings.Contains(buf.String(), "\033[") {
+		t.Fatalf("unexpected color codes in %q", buf.String())
+	}
+}
@@ -0,0 +1,284 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ParseLevel parses a level name such as "info", case-insensitively.
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return LevelWarn, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+func (lvl Level) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(lvl.String())), nil
+}
+
+func (lvl *Level) UnmarshalText(text []byte) error {
+	parsed, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*lvl = parsed
+	return nil
+}
+
+// levelTree holds the levels of a logger family. A named logger uses the
+// level set for its name, else for the closest dotted ancestor, else the
+// root level, which is stored under "". Every change bumps version so
+// cached levels are recomputed.
+type levelTree struct {
+	version atomic.Uint64
+
+	mu      sync.RWMutex
+	levels  map[string]Level
+	reverts map[string]*levelRevert
+}
+
+// levelRevert restores the setting a name had before a temporary change.
+type levelRevert struct {
+	timer  *time.Timer
+	at     time.Time
+	level  Level
+	wasSet bool
+}
+
+func newLevelTree(root Level) *levelTree {
+	t := &levelTree{
+		levels:  map[string]Level{"": root},
+		reverts: make(map[string]*levelRevert),
+	}
+	// A zero levelCache means not computed yet.
+	t.version.Store(1)
+	return t
+}
+
+func (t *levelTree) effective(name string) Level {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for {
+		if lvl, ok := t.levels[name]; ok {
+			return lvl
+		}
+		idx := strings.LastIndexByte(name, '.')
+		if idx < 0 {
+			return t.levels[""]
+		}
+		name = name[:idx]
+	}
+}
+
+// set sets the level of name or, when unset is true, makes it inherit again.
+// A positive revertAfter restores the setting from before the change once
+// it elapses; a later set replaces a pending revert but keeps its target.
+func (t *levelTree) set(name string, level Level, unset bool, revertAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev, wasSet := t.levels[name]
+	if r := t.reverts[name]; r != nil {
+		r.timer.Stop()
+		prev, wasSet = r.level, r.wasSet
+		delete(t.reverts, name)
+	}
+	t.put(name, level, !unset)
+	if revertAfter <= 0 {
+		return
+	}
+	r := &levelRevert{at: time.Now().Add(revertAfter), level: prev, wasSet: wasSet}
+	r.timer = time.AfterFunc(revertAfter, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.reverts[name] == r {
+			delete(t.reverts, name)
+			t.put(name, r.level, r.wasSet)
+		}
+	})
+	t.reverts[name] = r
+}
+
+func (t *levelTree) put(name string, level Level, ok bool) {
+	if ok || name == "" {
+		t.levels[name] = level
+	} else {
+		delete(t.levels, name)
+	}
+	t.version.Add(1)
+}
+
+// levelCache memoizes the effective level of one name as version<<8|level.
+type levelCache struct {
+	v atomic.Uint64
+}
+
+func (l *Logger) enabled(level Level) bool {
+	return l.effectiveLevel() <= level
+}
+
+func (l *Logger) effectiveLevel() Level {
+	version := l.levels.version.Load()
+	if c := l.levelCache.v.Load(); c>>8 == version {
+		return Level(int8(uint8(c)))
+	}
+	lvl := l.levels.effective(l.name)
+	l.levelCache.v.Store(version<<8 | uint64(uint8(lvl)))
+	return lvl
+}
+
+// Level returns the level l currently logs at.
+func (l *Logger) Level() Level {
+	return l.effectiveLevel()
+}
+
+// Name returns the dotted name given by Named, empty for a root logger.
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// Named returns a child logger named after l's name and name joined by a
+// dot. Its level follows the closest configured ancestor until set.
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	if l.name != "" && name != "" {
+		name = l.name + "." + name
+	} else if name == "" {
+		name = l.name
+	}
+	child.name = name
+	child.levelCache = new(levelCache)
+	return &child
+}
+
+// SetLevelFor sets the level of l's name and restores the previous setting
+// after d.
+func (l *Logger) SetLevelFor(level Level, d time.Duration) {
+	l.levels.set(l.name, level, false, d)
+}
+
+// ResetLevel makes a named logger inherit its level again. The root level
+// has nothing to inherit, so it is left unchanged.
+func (l *Logger) ResetLevel() {
+	if l.name == "" {
+		return
+	}
+	l.levels.set(l.name, 0, true, 0)
+}
+
+// Named returns a named child of the default logger. Its caller depth
+// already accounts for the package-level wrappers.
+func Named(name string) *Logger {
+	child := Default().Named(name)
+	child.SetCallerDepth(2)
+	return child
+}
+
+// LevelState is one configured level as served by LevelHandler.
+type LevelState struct {
+	Logger   string     `json:"logger"`
+	Level    *Level     `json:"level"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// levelRequest is the body of a PUT. A null level makes a named logger
+// inherit again; Duration, such as "10m", reverts the change after it.
+type levelRequest struct {
+	Logger   string `json:"logger"`
+	Level    *Level `json:"level"`
+	Duration string `json:"duration"`
+}
+
+// LevelHandler serves the levels of l's logger family. GET lists the
+// configured levels, or with ?logger=name the effective level of one
+// logger. PUT takes {"logger": "apollo.sync", "level": "debug",
+// "duration": "10m"}; an empty logger is the root.
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Query().Has("logger") {
+				name := r.URL.Query().Get("logger")
+				lvl := l.levels.effective(name)
+				writeJSON(w, http.StatusOK, LevelState{Logger: name, Level: &lvl})
+				return
+			}
+			writeJSON(w, http.StatusOK, l.levels.states())
+		case http.MethodPut:
+			var req levelRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			var d time.Duration
+			if req.Duration != "" {
+				var err error
+				if d, err = time.ParseDuration(req.Duration); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if req.Level == nil {
+				if req.Logger == "" {
+					http.Error(w, "the root logger needs a level", http.StatusBadRequest)
+					return
+				}
+				l.levels.set(req.Logger, 0, true, d)
+			} else {
+				l.levels.set(req.Logger, *req.Level, false, d)
+			}
+			lvl := l.levels.effective(req.Logger)
+			writeJSON(w, http.StatusOK, LevelState{Logger: req.Logger, Level: &lvl})
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (t *levelTree) states() []LevelState {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	states := make([]LevelState, 0, len(t.levels))
+	for name, lvl := range t.levels {
+		lvl := lvl
+		state := LevelState{Logger: name, Level: &lvl}
+		if r := t.reverts[name]; r != nil {
+			state.RevertAt = &r.at
+		}
+		states = append(states, state)
+	}
+	// Names that only have a pending revert currently inherit.
+	for name, r := range t.reverts {
+		if _, ok := t.levels[name]; !ok {
+			states = append(states, LevelState{Logger: name, RevertAt: &r.at})
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Logger < states[j].Logger
+	})
+	return states
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// LevelHandler serves the levels of the default logger and its named
+// children.
+func LevelHandler() http.Handler {
+	return Default().LevelHandler()
+}
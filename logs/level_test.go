@@ -0,0 +1,109 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Named_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	root := newTestLogger(&buf)
+	apollo := root.Named("apollo")
+	sync := apollo.Named("sync")
+	if sync.Name() != "apollo.sync" {
+		t.Fatalf("unexpected name %q", sync.Name())
+	}
+
+	apollo.SetLevel(LevelDebug)
+	sync.Debug("sync debug")
+	root.Debug("root debug")
+	if got := buf.String(); !strings.Contains(got, "apollo.sync sync debug") || strings.Contains(got, "root debug") {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	sync.SetLevel(LevelError)
+	if sync.Level() != LevelError || apollo.Level() != LevelDebug {
+		t.Fatalf("levels %v/%v", sync.Level(), apollo.Level())
+	}
+	sync.ResetLevel()
+	apollo.ResetLevel()
+	if sync.Level() != LevelInfo {
+		t.Fatalf("expected the root level after reset, got %v", sync.Level())
+	}
+
+	root.SetLevel(LevelWarn)
+	root.ResetLevel()
+	if root.Level() != LevelWarn || sync.Level() != LevelWarn {
+		t.Fatalf("root reset changed the levels to %v/%v", root.Level(), sync.Level())
+	}
+}
+
+func Test_SetLevelFor(t *testing.T) {
+	l := New().Named("rest")
+	l.SetLevel(LevelWarn)
+	l.SetLevelFor(LevelDebug, time.Hour)
+	l.SetLevelFor(LevelDebug, 20*time.Millisecond)
+	if l.Level() != LevelDebug {
+		t.Fatalf("expected debug, got %v", l.Level())
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for l.Level() != LevelWarn {
+		if time.Now().After(deadline) {
+			t.Fatalf("level was not reverted, still %v", l.Level())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_LevelHandler(t *testing.T) {
+	l := New()
+	server := httptest.NewServer(l.LevelHandler())
+	defer server.Close()
+
+	put := func(body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("put error: %s", err.Error())
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := put(`{"logger":"apollo","level":"debug","duration":"1h"}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	if resp := put(`{"logger":"apollo","level":"loud"}`); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d", resp.StatusCode)
+	}
+	if l.Named("apollo").Named("sync").Level() != LevelDebug {
+		t.Fatalf("level was not applied")
+	}
+
+	resp, err := http.Get(server.URL + "?logger=apollo.sync")
+	if err != nil {
+		t.Fatalf("get error: %s", err.Error())
+	}
+	var state LevelState
+	json.NewDecoder(resp.Body).Decode(&state)
+	resp.Body.Close()
+	if state.Logger != "apollo.sync" || state.Level == nil || *state.Level != LevelDebug {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("get error: %s", err.Error())
+	}
+	var states []LevelState
+	json.NewDecoder(resp.Body).Decode(&states)
+	resp.Body.Close()
+	if len(states) != 2 || states[0].Logger != "" || *states[0].Level != LevelInfo || states[1].RevertAt == nil {
+		t.Fatalf("unexpected states %+v", states)
+	}
+}
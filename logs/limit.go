@@ -0,0 +1,194 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingConfig limits the entries of one call site: within each Interval
+// the First entries are logged, then every Thereafter-th. A zero
+// Thereafter drops the rest of the interval.
+type SamplingConfig struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+// DedupeConfig collapses entries with the same level and message. The
+// first is logged; repeats within Interval are counted and reported by one
+// "(repeated N times)" entry when the interval ends, or earlier by Flush,
+// Close and Fatal.
+type DedupeConfig struct {
+	Interval time.Duration
+}
+
+type sampleKey struct {
+	pc    uintptr
+	level Level
+}
+
+type sampleCounter struct {
+	resetAt time.Time
+	n       int
+}
+
+type dedupeKey struct {
+	level   Level
+	message string
+}
+
+type repeatRecord struct {
+	entry *Entry
+	count int
+	timer *time.Timer
+	emit  func(*Entry)
+}
+
+func (r *repeatRecord) summary() *Entry {
+	summary := *r.entry
+	summary.Time = time.Now()
+	summary.Message = fmt.Sprintf("%s (repeated %d times)", r.entry.Message, r.count)
+	return &summary
+}
+
+// limiter holds sampling and dedupe state. It is shared by a Logger and
+// its children.
+type limiter struct {
+	enabled atomic.Bool
+
+	mu       sync.Mutex
+	sampling [LevelFatal + 1]SamplingConfig
+	dedupe   [LevelFatal + 1]DedupeConfig
+	counters map[sampleKey]*sampleCounter
+	repeats  map[dedupeKey]*repeatRecord
+	now      func() time.Time
+
+	lastSweep time.Time // of expired counters
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		counters: make(map[sampleKey]*sampleCounter),
+		repeats:  make(map[dedupeKey]*repeatRecord),
+		now:      time.Now,
+	}
+}
+
+// SetSampling sets the sampling of entries at level. A zero config turns
+// sampling off.
+func (l *Logger) SetSampling(level Level, config SamplingConfig) {
+	if level < LevelDebug || level > LevelFatal {
+		return
+	}
+	l.limiter.mu.Lock()
+	defer l.limiter.mu.Unlock()
+	l.limiter.sampling[level] = config
+	l.limiter.updateEnabled()
+}
+
+// SetDedupe sets the collapsing of repeated entries at level. A zero
+// config turns it off.
+func (l *Logger) SetDedupe(level Level, config DedupeConfig) {
+	if level < LevelDebug || level > LevelFatal {
+		return
+	}
+	l.limiter.mu.Lock()
+	defer l.limiter.mu.Unlock()
+	l.limiter.dedupe[level] = config
+	l.limiter.updateEnabled()
+}
+
+func (lim *limiter) updateEnabled() {
+	for lvl := LevelDebug; lvl <= LevelFatal; lvl++ {
+		if lim.sampling[lvl].Interval > 0 || lim.dedupe[lvl].Interval > 0 {
+			lim.enabled.Store(true)
+			return
+		}
+	}
+	lim.enabled.Store(false)
+}
+
+// allow reports whether e should be written. emit writes the summary of
+// suppressed repeats once a dedupe interval ends.
+func (lim *limiter) allow(e *Entry, emit func(*Entry)) bool {
+	if !lim.enabled.Load() || e.Level < LevelDebug || e.Level > LevelFatal {
+		return true
+	}
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	now := lim.now()
+
+	if cfg := lim.sampling[e.Level]; cfg.Interval > 0 {
+		if now.Sub(lim.lastSweep) >= cfg.Interval {
+			lim.sweep(now)
+		}
+		key := sampleKey{pc: e.pc, level: e.Level}
+		c := lim.counters[key]
+		if c == nil || !now.Before(c.resetAt) {
+			c = &sampleCounter{resetAt: now.Add(cfg.Interval)}
+			lim.counters[key] = c
+		}
+		c.n++
+		if c.n > cfg.First && (cfg.Thereafter <= 0 || (c.n-cfg.First)%cfg.Thereafter != 0) {
+			return false
+		}
+	}
+
+	if cfg := lim.dedupe[e.Level]; cfg.Interval > 0 {
+		key := dedupeKey{level: e.Level, message: e.Message}
+		if r := lim.repeats[key]; r != nil {
+			r.count++
+			return false
+		}
+		r := &repeatRecord{entry: e, emit: emit}
+		lim.repeats[key] = r
+		r.timer = time.AfterFunc(cfg.Interval, func() {
+			lim.mu.Lock()
+			// flush may have taken the record already.
+			current := lim.repeats[key] == r
+			if current {
+				delete(lim.repeats, key)
+			}
+			lim.mu.Unlock()
+			if current && r.count > 0 {
+				emit(r.summary())
+			}
+		})
+	}
+	return true
+}
+
+// sweep drops the sampling counters whose interval is over. It must be
+// called with lim.mu held.
+func (lim *limiter) sweep(now time.Time) {
+	lim.lastSweep = now
+	for key, c := range lim.counters {
+		if !now.Before(c.resetAt) {
+			delete(lim.counters, key)
+		}
+	}
+}
+
+// flush ends every pending dedupe interval now and writes the summaries of
+// the repeats counted so far.
+func (lim *limiter) flush() {
+	lim.mu.Lock()
+	var pending []*repeatRecord
+	for key, r := range lim.repeats {
+		r.timer.Stop()
+		delete(lim.repeats, key)
+		if r.count > 0 {
+			pending = append(pending, r)
+		}
+	}
+	lim.mu.Unlock()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].entry.Time.Before(pending[j].entry.Time)
+	})
+	for _, r := range pending {
+		r.emit(r.summary())
+	}
+}
@@ -0,0 +1,122 @@
+package logs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetSampling(LevelInfo, SamplingConfig{Interval: time.Minute, First: 2, Thereafter: 3})
+	now := time.Now()
+	l.limiter.now = func() time.Time { return now }
+
+	for i := 1; i <= 10; i++ {
+		l.Info("hot %d", i)
+	}
+	l.Info("other call site")
+	l.Warn("not sampled")
+	for _, want := range []string{"hot 1\n", "hot 2\n", "hot 5\n", "hot 8\n", "other call site", "not sampled"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("missing %q in %q", want, buf.String())
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 6 {
+		t.Fatalf("expected 6 lines, got %d: %q", n, buf.String())
+	}
+
+	buf.Reset()
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		l.Info("next interval")
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatalf("expected 2 lines after reset, got %d", n)
+	}
+}
+
+func Test_Dedupe(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	l := New()
+	l.SetOutput(w)
+	l.SetDedupe(LevelError, DedupeConfig{Interval: 50 * time.Millisecond})
+
+	for i := 0; i < 5; i++ {
+		l.Error("connection refused")
+	}
+	l.Error("another")
+	if got := w.String(); strings.Count(got, "connection refused") != 1 || !strings.Contains(got, "another") {
+		t.Fatalf("unexpected output %q", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(w.String(), "connection refused (repeated 4 times)") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary in %q", w.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(w.String(), "another (repeated") {
+		t.Fatalf("summary for a message that was not repeated: %q", w.String())
+	}
+}
+
+func Test_DedupeFlushAndClose(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetDedupe(LevelError, DedupeConfig{Interval: 50 * time.Millisecond})
+	for i := 0; i < 3; i++ {
+		l.Error("disk full")
+	}
+	if err := l.Flush(); err != nil {
+		t.Fatalf("flush error: %s", err.Error())
+	}
+	if !strings.Contains(buf.String(), "disk full (repeated 2 times)") {
+		t.Fatalf("flush did not write the summary: %q", buf.String())
+	}
+	// The stopped timer does not report the repeats a second time.
+	time.Sleep(100 * time.Millisecond)
+	if n := strings.Count(buf.String(), "(repeated"); n != 1 {
+		t.Fatalf("%d summaries written: %q", n, buf.String())
+	}
+
+	w := &gateWriter{gate: make(chan struct{})}
+	close(w.gate)
+	closing := New()
+	closing.SetOutput(NewAsyncWriter(w, AsyncConfig{}))
+	closing.SetDedupe(LevelError, DedupeConfig{Interval: time.Hour})
+	closing.Error("timeout")
+	closing.Error("timeout")
+	if err := closing.Close(); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+	if !strings.Contains(w.String(), "timeout (repeated 1 times)") {
+		t.Fatalf("close did not write the summary: %q", w.String())
+	}
+	if len(closing.limiter.repeats) != 0 {
+		t.Fatalf("pending repeats after close: %d", len(closing.limiter.repeats))
+	}
+}
+
+func Test_SamplingPrunesCounters(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetSampling(LevelInfo, SamplingConfig{Interval: time.Minute, First: 1})
+	now := time.Now()
+	l.limiter.now = func() time.Time { return now }
+
+	l.Info("first call site")
+	l.Info("second call site")
+	if n := len(l.limiter.counters); n != 2 {
+		t.Fatalf("%d counters, want 2", n)
+	}
+	now = now.Add(time.Minute)
+	l.Info("third call site")
+	if n := len(l.limiter.counters); n != 1 {
+		t.Fatalf("%d counters after the interval, want 1", n)
+	}
+}
@@ -3,11 +3,11 @@ package logs
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-	"runtime"
 	"strings"
-	"time"
+	"sync"
 )
 
 // fork from https://github.com/Kidsunbo/kie_toolbox_go/blob/master/logs/logs.go
@@ -21,6 +21,11 @@ func init() {
 
 type RequestID struct{}
 
+// TraceParent and TraceState key the W3C trace context headers of the
+// current request in a context, as strings.
+type TraceParent struct{}
+type TraceState struct{}
+
 type Level int8
 
 const (
@@ -41,40 +46,108 @@ var colorFormat = []string{
 
 func New() *Logger {
 	return &Logger{
-		logger:      log.New(os.Stdout, " ", 0),
-		level:       LevelInfo,
+		sinks:       newSinkSet(Sink{Level: LevelDebug, Writer: os.Stdout}),
+		limiter:     newLimiter(),
+		levels:      newLevelTree(LevelInfo),
+		levelCache:  new(levelCache),
 		ctxFunc:     defaultContextFunction,
 		pathLength:  1,
 		callerDepth: 2,
 		msgPrefix:   "",
+		exitCode:    defaultExitCode,
+		stackLevel:  stackDisabled,
 	}
 }
 
 type Logger struct {
-	logger      *log.Logger
-	level       Level
+	sinks       *sinkSet
+	limiter     *limiter
+	handler     slog.Handler
+	name        string
+	levels      *levelTree
+	levelCache  *levelCache
 	ctxFunc     func(ctx context.Context) string
 	pathLength  int8
 	callerDepth int
 	msgPrefix   string
+	fields      []Field
+	fatalPolicy FatalPolicy
+	exitCode    int
+	stackLevel  Level
 }
 
+// SetLevel sets the level of l's name, the root level for an unnamed
+// logger. It is safe to call while logging.
 func (l *Logger) SetLevel(level Level) {
-	l.level = level
+	l.levels.set(l.name, level, false, 0)
 }
 
-func (l *Logger) SetOutputFile(filename string) {
+// SetOutputFile appends to filename, or writes to stderr when it is empty.
+// On error the output is left unchanged.
+func (l *Logger) SetOutputFile(filename string) error {
 	if filename == "" {
-		l.logger.SetOutput(os.Stderr)
-	} else {
-		file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-		if err != nil {
-			log.Fatal(err)
+		l.SetOutput(os.Stderr)
+		return nil
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	l.setOutput(file, file)
+	return nil
+}
+
+// SetRotatingOutputFile writes to filename through a RotatingFile.
+func (l *Logger) SetRotatingOutputFile(filename string, config RotateConfig) error {
+	file, err := NewRotatingFile(filename, config)
+	if err != nil {
+		return err
+	}
+	l.setOutput(file, file)
+	return nil
+}
+
+// SetOutput sets the writer of the default sink. The text formatter colors
+// its output only when w is a terminal. A file opened by SetOutputFile or
+// SetRotatingOutputFile is closed once replaced.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.setOutput(w, nil)
+}
+
+// setOutput sets the default sink writer to w and closes the file the
+// logger opened for the previous one. owned is w when the logger opened it.
+func (l *Logger) setOutput(w io.Writer, owned io.Closer) {
+	var replaced io.Closer
+	var mu *sync.Mutex
+	l.sinks.updateDefault(func(s *Sink) {
+		s.Writer = w
+		replaced, l.sinks.owned = l.sinks.owned, owned
+	})
+	if replaced == nil {
+		return
+	}
+	for _, s := range l.sinks.load() {
+		if s.id == DefaultSink {
+			mu = s.mu
 		}
-		l.logger.SetOutput(file)
+	}
+	// Entries being written to the old file finish first.
+	if mu != nil {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	if err := replaced.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "logs: close replaced output: %v\n", err)
 	}
 }
 
+// SetFormatter sets the formatter of the default sink.
+func (l *Logger) SetFormatter(f Formatter) {
+	l.sinks.updateDefault(func(s *Sink) {
+		s.Formatter = f
+	})
+}
+
 func (l *Logger) SetCallerDepth(depth int) {
 	l.callerDepth = depth
 }
@@ -87,21 +160,7 @@ func (l *Logger) SetMessagePrefix(prefix string) {
 	l.msgPrefix = prefix
 }
 
-func (l *Logger) getLogMetaInfo() string {
-	pc, file, line, ok := runtime.Caller(l.callerDepth)
-	if !ok {
-		return ""
-	}
-
-	currentDatetime := l.getCurrentDatetime()
-	currentFilePosition := l.getFilePosition(file, line)
-	currentFunctionName := l.getCurrentFunctionName(pc)
-
-	return fmt.Sprintf("%v %v [%v]", currentDatetime, currentFilePosition, currentFunctionName)
-}
-
-func (l *Logger) getCurrentFunctionName(pc uintptr) string {
-	functionName := runtime.FuncForPC(pc).Name()
+func (l *Logger) getCurrentFunctionName(functionName string) string {
 	idx := strings.LastIndex(functionName, ".")
 	if idx == -1 {
 		return functionName
@@ -111,10 +170,6 @@ func (l *Logger) getCurrentFunctionName(pc uintptr) string {
 	return functionName[idx+1:]
 }
 
-func (l *Logger) getCurrentDatetime() string {
-	return time.Now().Format("2006-01-02 15:04:05.000")
-}
-
 func (l *Logger) getFilePosition(file string, line int) string {
 	idx := len(file) - 1
 	var count int8
@@ -140,105 +195,75 @@ func (l *Logger) SetContextFunction(f func(ctx context.Context) string) {
 }
 
 func (l *Logger) Debug(format string, values ...interface{}) {
-	if l.level > LevelDebug {
+	if !l.enabled(LevelDebug) {
 		return
 	}
-	rawLog := fmt.Sprintf(colorFormat[LevelDebug], stringify("[DEBUG] ", l.getLogMetaInfo(), fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(nil, LevelDebug, format, values)
 }
 
 func (l *Logger) CtxDebug(ctx context.Context, format string, values ...interface{}) {
-	if l.level > LevelDebug {
+	if !l.enabled(LevelDebug) {
 		return
 	}
-	var ctxStr string
-	if l.ctxFunc != nil {
-		ctxStr = l.ctxFunc(ctx)
-	}
-	rawLog := fmt.Sprintf(colorFormat[LevelDebug], stringify("[DEBUG] ", l.getLogMetaInfo(), ctxStr, fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(ctx, LevelDebug, format, values)
 }
 
 func (l *Logger) Info(format string, values ...interface{}) {
-	if l.level > LevelInfo {
+	if !l.enabled(LevelInfo) {
 		return
 	}
-	rawLog := fmt.Sprintf(colorFormat[LevelInfo], stringify("[INFO] ", l.getLogMetaInfo(), fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(nil, LevelInfo, format, values)
 }
 
 func (l *Logger) CtxInfo(ctx context.Context, format string, values ...interface{}) {
-	if l.level > LevelInfo {
+	if !l.enabled(LevelInfo) {
 		return
 	}
-	var ctxStr string
-	if l.ctxFunc != nil {
-		ctxStr = l.ctxFunc(ctx)
-	}
-	rawLog := fmt.Sprintf(colorFormat[LevelInfo], stringify("[INFO] ", l.getLogMetaInfo(), ctxStr, fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(ctx, LevelInfo, format, values)
 }
 
 func (l *Logger) Warn(format string, values ...interface{}) {
-	if l.level > LevelWarn {
+	if !l.enabled(LevelWarn) {
 		return
 	}
-	rawLog := fmt.Sprintf(colorFormat[LevelWarn], stringify("[WARN] ", l.getLogMetaInfo(), fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(nil, LevelWarn, format, values)
 }
 
 func (l *Logger) CtxWarn(ctx context.Context, format string, values ...interface{}) {
-	if l.level > LevelWarn {
+	if !l.enabled(LevelWarn) {
 		return
 	}
-	var ctxStr string
-	if l.ctxFunc != nil {
-		ctxStr = l.ctxFunc(ctx)
-	}
-	rawLog := fmt.Sprintf(colorFormat[LevelWarn], stringify("[WARN] ", l.getLogMetaInfo(), ctxStr, fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(ctx, LevelWarn, format, values)
 }
 
 func (l *Logger) Error(format string, values ...interface{}) {
-	if l.level > LevelError {
+	if !l.enabled(LevelError) {
 		return
 	}
-	rawLog := fmt.Sprintf(colorFormat[LevelError], stringify("[ERROR] ", l.getLogMetaInfo(), fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(nil, LevelError, format, values)
 }
 
 func (l *Logger) CtxError(ctx context.Context, format string, values ...interface{}) {
-	if l.level > LevelError {
+	if !l.enabled(LevelError) {
 		return
 	}
-	var ctxStr string
-	if l.ctxFunc != nil {
-		ctxStr = l.ctxFunc(ctx)
-	}
-	rawLog := fmt.Sprintf(colorFormat[LevelError], stringify("[ERROR] ", l.getLogMetaInfo(), ctxStr, fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
+	l.logf(ctx, LevelError, format, values)
 }
 
 func (l *Logger) Fatal(format string, values ...interface{}) {
-	if l.level > LevelFatal {
+	if !l.enabled(LevelFatal) {
 		return
 	}
-	rawLog := fmt.Sprintf(colorFormat[LevelFatal], stringify("[FATAL] ", l.getLogMetaInfo(), fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
-	panic("panic happened because fatal is reported")
+	l.logf(nil, LevelFatal, format, values)
+	l.fatal()
 }
 
 func (l *Logger) CtxFatal(ctx context.Context, format string, values ...interface{}) {
-	if l.level > LevelFatal {
+	if !l.enabled(LevelFatal) {
 		return
 	}
-	var ctxStr string
-	if l.ctxFunc != nil {
-		ctxStr = l.ctxFunc(ctx)
-	}
-	rawLog := fmt.Sprintf(colorFormat[LevelFatal], stringify("[FATAL] ", l.getLogMetaInfo(), ctxStr, fmt.Sprintf(l.msgPrefix+format, values...)))
-	l.logger.Println(rawLog)
-	panic("panic happened because fatal is reported")
+	l.logf(ctx, LevelFatal, format, values)
+	l.fatal()
 }
 
 func defaultContextFunction(ctx context.Context) string {
@@ -260,6 +285,14 @@ func Default() *Logger {
 	return logger
 }
 
+func Flush() error {
+	return Default().Flush()
+}
+
+func Close() error {
+	return Default().Close()
+}
+
 func Debug(format string, values ...interface{}) {
 	Default().Debug(format, values...)
 }
@@ -0,0 +1,150 @@
+// Package logtest records entries of a logs.Logger in memory so tests can
+// assert on what was logged, and routes log output to testing.TB.
+package logtest
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+// Recorder keeps copies of the entries passed to it. It is a logs.Formatter
+// that outputs nothing, so it can back a logs.Sink.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []logs.Entry
+}
+
+// NewLogger returns a logger at level whose only sink records into the
+// returned Recorder.
+func NewLogger(level logs.Level) (*logs.Logger, *Recorder) {
+	rec := &Recorder{}
+	l := logs.New()
+	l.SetLevel(level)
+	l.RemoveSink(logs.DefaultSink)
+	l.AddSink(rec.Sink())
+	return l, rec
+}
+
+// Sink returns a sink recording every entry into r.
+func (r *Recorder) Sink() logs.Sink {
+	return logs.Sink{Level: logs.LevelDebug, Formatter: r, Writer: io.Discard}
+}
+
+func (r *Recorder) Format(e *logs.Entry) ([]byte, error) {
+	entry := *e
+	entry.Fields = append([]logs.Field(nil), e.Fields...)
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+	return nil, nil
+}
+
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// All returns the recorded entries in order.
+func (r *Recorder) All() []logs.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]logs.Entry(nil), r.entries...)
+}
+
+// TakeAll returns the recorded entries and clears r.
+func (r *Recorder) TakeAll() []logs.Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries
+	r.entries = nil
+	return entries
+}
+
+// FilterLevel returns a Recorder with the entries at exactly level.
+func (r *Recorder) FilterLevel(level logs.Level) *Recorder {
+	return r.filter(func(e *logs.Entry) bool {
+		return e.Level == level
+	})
+}
+
+// FilterMessageContains returns a Recorder with the entries whose message
+// contains s.
+func (r *Recorder) FilterMessageContains(s string) *Recorder {
+	return r.filter(func(e *logs.Entry) bool {
+		return strings.Contains(e.Message, s)
+	})
+}
+
+// FilterField returns a Recorder with the entries having a field key equal
+// to value.
+func (r *Recorder) FilterField(key string, value interface{}) *Recorder {
+	return r.filter(func(e *logs.Entry) bool {
+		for _, f := range e.Fields {
+			if f.Key == key && reflect.DeepEqual(f.Value, value) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (r *Recorder) filter(keep func(*logs.Entry) bool) *Recorder {
+	filtered := &Recorder{}
+	for _, e := range r.All() {
+		if keep(&e) {
+			filtered.entries = append(filtered.entries, e)
+		}
+	}
+	return filtered
+}
+
+// TBWriter writes each line to t.Log. Lines written after the test has
+// finished are dropped, as t.Log would panic.
+type TBWriter struct {
+	t    testing.TB
+	mu   sync.Mutex
+	done bool
+}
+
+func NewTBWriter(t testing.TB) *TBWriter {
+	w := &TBWriter{t: t}
+	t.Cleanup(func() {
+		w.mu.Lock()
+		w.done = true
+		w.mu.Unlock()
+	})
+	return w
+}
+
+func (w *TBWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done {
+		return len(p), nil
+	}
+	w.t.Helper()
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.t.Log(line)
+	}
+	return len(p), nil
+}
+
+// TBSink returns a sink writing uncolored text lines to t.Log.
+func TBSink(t testing.TB) logs.Sink {
+	return logs.Sink{Level: logs.LevelDebug, Formatter: &logs.TextFormatter{Color: logs.ColorNever}, Writer: NewTBWriter(t)}
+}
+
+// NewTestLogger returns a debug level logger writing to t.Log.
+func NewTestLogger(t testing.TB) *logs.Logger {
+	l := logs.New()
+	l.SetLevel(logs.LevelDebug)
+	l.RemoveSink(logs.DefaultSink)
+	l.AddSink(TBSink(t))
+	return l
+}
@@ -0,0 +1,61 @@
+package logtest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+func Test_Recorder(t *testing.T) {
+	l, rec := NewLogger(logs.LevelInfo)
+	l.Debug("dropped")
+	l.Info("started %s", "sync")
+	l.With("component", "apollo").Warnw("slow sync", "took", 3)
+	l.Error("sync failed")
+
+	if rec.Len() != 3 {
+		t.Fatalf("expected 3 entries, got %d", rec.Len())
+	}
+	warns := rec.FilterLevel(logs.LevelWarn).All()
+	if len(warns) != 1 || warns[0].Message != "slow sync" || len(warns[0].Fields) != 2 ||
+		!strings.HasPrefix(warns[0].Caller, "logtest_test.go:") || warns[0].Function != "Test_Recorder" {
+		t.Fatalf("unexpected warn entries %+v", warns)
+	}
+	if n := rec.FilterMessageContains("sync").Len(); n != 3 {
+		t.Fatalf("expected 3 entries mentioning sync, got %d", n)
+	}
+	if n := rec.FilterField("component", "apollo").Len(); n != 1 {
+		t.Fatalf("expected 1 entry with the field, got %d", n)
+	}
+	if all := rec.TakeAll(); len(all) != 3 || rec.Len() != 0 {
+		t.Fatalf("TakeAll returned %d entries, %d left", len(all), rec.Len())
+	}
+}
+
+type fakeTB struct {
+	testing.TB
+	lines    []string
+	cleanups []func()
+}
+
+func (f *fakeTB) Helper()                 {}
+func (f *fakeTB) Log(args ...interface{}) { f.lines = append(f.lines, args[0].(string)) }
+func (f *fakeTB) Cleanup(fn func())       { f.cleanups = append(f.cleanups, fn) }
+
+func Test_NewTestLogger(t *testing.T) {
+	tb := &fakeTB{}
+	l := NewTestLogger(tb)
+	l.Debugw("hello", "n", 1)
+	if len(tb.lines) != 1 || !strings.HasPrefix(tb.lines[0], "[DEBUG] ") || !strings.HasSuffix(tb.lines[0], "hello n=1") {
+		t.Fatalf("unexpected lines %q", tb.lines)
+	}
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	l.Info("after the test")
+	if len(tb.lines) != 1 {
+		t.Fatalf("logged after cleanup: %q", tb.lines)
+	}
+	NewTestLogger(t).Info("routed to t.Log")
+}
@@ -0,0 +1,380 @@
+package logs
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RotateInterval int8
+
+const (
+	RotateNever RotateInterval = iota
+	RotateHourly
+	RotateDaily
+)
+
+// RotateConfig controls when a RotatingFile rotates and which backups it
+// keeps. Zero values disable the corresponding limit.
+type RotateConfig struct {
+	MaxSize    int64 // bytes
+	Interval   RotateInterval
+	MaxBackups int
+	MaxAge     time.Duration
+	Compress   bool // gzip rotated files
+
+	// OnError receives errors of the background cleanup. It defaults to
+	// printing them to stderr.
+	OnError func(error)
+}
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+
+	// reopenCheckInterval bounds how often Write checks whether the file
+	// was moved away by an external tool such as logrotate.
+	reopenCheckInterval = time.Second
+)
+
+// RotatingFile is an io.WriteCloser that writes to a file and rotates it by
+// size and time. Rotated files are renamed to name-<timestamp>.ext next to
+// it, with a .N sequence before the extension when that name is taken.
+// When the file is moved or removed by someone else it is recreated.
+type RotatingFile struct {
+	filename string
+	config   RotateConfig
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+	lastCheck    time.Time
+	closed       bool
+
+	cleanupMu sync.Mutex
+	cleanupWg sync.WaitGroup
+
+	now        func() time.Time
+	checkEvery time.Duration
+}
+
+func NewRotatingFile(filename string, config RotateConfig) (*RotatingFile, error) {
+	f := &RotatingFile{
+		filename:   filename,
+		config:     config,
+		now:        time.Now,
+		checkEvery: reopenCheckInterval,
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		// An earlier rotation could not open the new file.
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	now := f.now()
+	if now.Sub(f.lastCheck) >= f.checkEvery {
+		f.lastCheck = now
+		if err := f.reopenIfMoved(); err != nil {
+			return 0, err
+		}
+	}
+	if (!f.nextRotation.IsZero() && !now.Before(f.nextRotation)) ||
+		(f.config.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.config.MaxSize) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it to a backup and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for a running cleanup of backups.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return nil
+	}
+	f.closed = true
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+	}
+	f.mu.Unlock()
+	f.cleanupWg.Wait()
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	// An existing file is rotated once the period it was last written in
+	// is over.
+	start := f.now()
+	if info.Size() > 0 {
+		start = info.ModTime()
+	}
+	f.nextRotation = nextBoundary(start, f.config.Interval)
+	return nil
+}
+
+func (f *RotatingFile) reopenIfMoved() error {
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	onDisk, err := os.Stat(f.filename)
+	if err == nil && os.SameFile(current, onDisk) {
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f.file.Close()
+	f.file = nil
+	return f.open()
+}
+
+// rotate leaves f.file nil only when no file can be opened; Write retries
+// opening it then.
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		// Keep writing to the same file.
+		return errors.Join(err, f.open())
+	}
+	backup := uniqueBackupName(f.filename, f.now())
+	if err := os.Rename(f.filename, backup); err != nil && !os.IsNotExist(err) {
+		// Keep writing to the old file rather than losing entries.
+		return errors.Join(err, f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if f.config.Compress || f.config.MaxBackups > 0 || f.config.MaxAge > 0 {
+		f.cleanupWg.Add(1)
+		go func() {
+			defer f.cleanupWg.Done()
+			f.cleanupMu.Lock()
+			defer f.cleanupMu.Unlock()
+			if err := f.cleanup(); err != nil {
+				f.reportError(err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (f *RotatingFile) reportError(err error) {
+	if f.config.OnError != nil {
+		f.config.OnError(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "logs: rotate %s: %v\n", f.filename, err)
+}
+
+type backupFile struct {
+	path string
+	time time.Time
+	seq  int
+}
+
+// cleanup compresses and removes backups according to the config.
+func (f *RotatingFile) cleanup() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+	cutoff := time.Time{}
+	if f.config.MaxAge > 0 {
+		cutoff = f.now().Add(-f.config.MaxAge)
+	}
+
+	var errs []error
+	for i, b := range backups {
+		if (f.config.MaxBackups > 0 && i >= f.config.MaxBackups) || (!cutoff.IsZero() && b.time.Before(cutoff)) {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if f.config.Compress && !strings.HasSuffix(b.path, ".gz") {
+			if err := compressFile(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("cleanup backups: %v", errs)
+	}
+	return nil
+}
+
+// backups lists the rotated files of f, newest first.
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir := filepath.Dir(f.filename)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	prefix, ext := backupPrefixExt(f.filename)
+	var backups []backupFile
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".gz")
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := name[len(prefix) : len(name)-len(ext)]
+		if len(stamp) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, stamp[:len(backupTimeFormat)], time.Local)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if rest := stamp[len(backupTimeFormat):]; rest != "" {
+			if seq, err = strconv.Atoi(strings.TrimPrefix(rest, ".")); err != nil || rest[0] != '.' || seq <= 0 {
+				continue
+			}
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, entry.Name()), time: t, seq: seq})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].time.Equal(backups[j].time) {
+			return backups[i].time.After(backups[j].time)
+		}
+		return backups[i].seq > backups[j].seq
+	})
+	return backups, nil
+}
+
+func backupPrefixExt(filename string) (string, string) {
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	return strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func backupName(filename string, t time.Time, seq int) string {
+	prefix, ext := backupPrefixExt(filename)
+	stamp := t.Format(backupTimeFormat)
+	if seq > 0 {
+		stamp += "." + strconv.Itoa(seq)
+	}
+	return filepath.Join(filepath.Dir(filename), prefix+stamp+ext)
+}
+
+// uniqueBackupName returns the first backup name for t that is not taken,
+// compressed or not, so rotations within a millisecond keep every backup.
+func uniqueBackupName(filename string, t time.Time) string {
+	for seq := 0; ; seq++ {
+		name := backupName(filename, t, seq)
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !os.IsNotExist(err)
+}
+
+func nextBoundary(t time.Time, interval RotateInterval) time.Time {
+	switch interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+".gz")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(path)
+}
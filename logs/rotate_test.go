@@ -0,0 +1,174 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir error: %s", err.Error())
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func Test_RotatingFile_Size(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(filename, RotateConfig{MaxSize: 10, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("open error: %s", err.Error())
+	}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("write error: %s", err.Error())
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+
+	// Three rotations happened; only the two newest backups are kept.
+	got := listDir(t, dir)
+	if len(got) != 3 || !strings.HasSuffix(got[0], ".log.gz") || !strings.HasSuffix(got[1], ".log.gz") || got[2] != "app.log" {
+		t.Fatalf("unexpected files %v", got)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "fourth\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func Test_RotatingFile_Daily(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	now := time.Now()
+	f, err := NewRotatingFile(filename, RotateConfig{Interval: RotateDaily})
+	if err != nil {
+		t.Fatalf("open error: %s", err.Error())
+	}
+	defer f.Close()
+	f.now = func() time.Time { return now }
+
+	f.Write([]byte("today\n"))
+	now = now.Add(24 * time.Hour)
+	f.Write([]byte("tomorrow\n"))
+
+	if got := listDir(t, dir); len(got) != 2 {
+		t.Fatalf("expected one backup, got %v", got)
+	}
+	data, _ := os.ReadFile(filename)
+	if string(data) != "tomorrow\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func Test_RotatingFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(filename, RotateConfig{})
+	if err != nil {
+		t.Fatalf("open error: %s", err.Error())
+	}
+	defer f.Close()
+	f.checkEvery = 0
+
+	f.Write([]byte("before\n"))
+	if err := os.Rename(filename, filename+".1"); err != nil {
+		t.Fatalf("rename error: %s", err.Error())
+	}
+	f.Write([]byte("after\n"))
+
+	data, _ := os.ReadFile(filename)
+	if string(data) != "after\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+}
+
+func Test_SetOutputFile_Error(t *testing.T) {
+	l := New()
+	if err := l.SetOutputFile(filepath.Join(t.TempDir(), "missing", "app.log")); err == nil {
+		t.Fatalf("expected error for missing directory")
+	}
+}
+
+func Test_RotatingFile_SameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+	f, err := NewRotatingFile(filename, RotateConfig{MaxBackups: 3})
+	if err != nil {
+		t.Fatalf("open error: %s", err.Error())
+	}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	f.now = func() time.Time { return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		f.Write([]byte(line))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("rotate error: %s", err.Error())
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+
+	want := []string{"app-2024-05-01T10-00-00.000.1.log", "app-2024-05-01T10-00-00.000.2.log", "app-2024-05-01T10-00-00.000.log", "app.log"}
+	if got := listDir(t, dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	backups, _ := f.backups()
+	var contents []string
+	for _, b := range backups {
+		data, _ := os.ReadFile(b.path)
+		contents = append(contents, string(data))
+	}
+	if strings.Join(contents, "") != "third\nsecond\nfirst\n" {
+		t.Fatalf("backups newest first = %q", contents)
+	}
+}
+
+func Test_SetOutputFile_ClosesReplaced(t *testing.T) {
+	dir := t.TempDir()
+	l := New()
+	if err := l.SetOutputFile(filepath.Join(dir, "a.log")); err != nil {
+		t.Fatalf("set output error: %s", err.Error())
+	}
+	first := l.sinks.owned.(*os.File)
+	if err := l.SetRotatingOutputFile(filepath.Join(dir, "b.log"), RotateConfig{}); err != nil {
+		t.Fatalf("set output error: %s", err.Error())
+	}
+	if _, err := first.Write([]byte("x")); err == nil {
+		t.Fatalf("replaced output file is still open")
+	}
+	second := l.sinks.owned.(*RotatingFile)
+
+	// A writer passed to SetOutput belongs to the caller and is not closed.
+	var buf strings.Builder
+	l.SetOutput(&buf)
+	if _, err := second.Write([]byte("x")); err != os.ErrClosed {
+		t.Fatalf("replaced rotating file write error = %v, want os.ErrClosed", err)
+	}
+	if l.sinks.owned != nil {
+		t.Fatalf("caller's writer recorded as owned")
+	}
+	l.Info("kept")
+	if !strings.Contains(buf.String(), "kept") {
+		t.Fatalf("output = %q", buf.String())
+	}
+}
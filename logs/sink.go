@@ -0,0 +1,158 @@
+package logs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// Sink is one destination of a Logger. It receives entries at or above
+// Level that passed the Logger's own level. A nil Formatter means text.
+type Sink struct {
+	Level     Level
+	Formatter Formatter
+	Writer    io.Writer
+}
+
+// SinkID identifies a sink added with AddSink. The output configured by
+// SetOutput and SetFormatter is the sink DefaultSink.
+type SinkID uint64
+
+const DefaultSink SinkID = 0
+
+type sink struct {
+	id        SinkID
+	level     Level
+	formatter Formatter
+	w         io.Writer
+	terminal  bool
+	mu        *sync.Mutex // shared by the versions of the same sink
+}
+
+func newSink(id SinkID, s Sink, mu *sync.Mutex) *sink {
+	if s.Formatter == nil {
+		s.Formatter = &TextFormatter{}
+	}
+	if mu == nil {
+		mu = new(sync.Mutex)
+	}
+	return &sink{id: id, level: s.Level, formatter: s.Formatter, w: s.Writer, terminal: isTerminal(s.Writer), mu: mu}
+}
+
+func (s *sink) write(e *Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.terminal = s.terminal
+	data, err := s.formatter.Format(e)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logs: format entry: %v\n", err)
+		return
+	}
+	if _, err := s.w.Write(data); err != nil {
+		fmt.Fprintf(os.Stderr, "logs: write entry: %v\n", err)
+	}
+}
+
+// sinkSet is shared by a Logger and its children. Writers load the current
+// list without locking; changes replace it under mu.
+type sinkSet struct {
+	mu     sync.Mutex
+	nextID SinkID
+	list   atomic.Pointer[[]*sink]
+	owned  io.Closer // writer of the default sink opened by the logger
+	async  atomic.Pointer[queue[*Entry]]
+}
+
+func newSinkSet(defaultSink Sink) *sinkSet {
+	set := &sinkSet{nextID: DefaultSink + 1}
+	list := []*sink{newSink(DefaultSink, defaultSink, nil)}
+	set.list.Store(&list)
+	return set
+}
+
+func (set *sinkSet) load() []*sink {
+	return *set.list.Load()
+}
+
+func (set *sinkSet) emit(e *Entry) {
+	for _, s := range set.load() {
+		if e.Level >= s.level {
+			s.write(e)
+		}
+	}
+}
+
+// update replaces the list with the result of f applied to a copy of it.
+func (set *sinkSet) update(f func([]*sink) []*sink) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	list := f(append([]*sink(nil), set.load()...))
+	set.list.Store(&list)
+}
+
+// updateDefault changes the default sink, re-adding it if it was removed.
+func (set *sinkSet) updateDefault(f func(*Sink)) {
+	set.update(func(list []*sink) []*sink {
+		for i, s := range list {
+			if s.id == DefaultSink {
+				cfg := Sink{Level: s.level, Formatter: s.formatter, Writer: s.w}
+				f(&cfg)
+				list[i] = newSink(DefaultSink, cfg, s.mu)
+				return list
+			}
+		}
+		cfg := Sink{Level: LevelDebug, Writer: os.Stdout}
+		f(&cfg)
+		return append([]*sink{newSink(DefaultSink, cfg, nil)}, list...)
+	})
+}
+
+// AddSink adds a destination. The sinks are shared with loggers created by
+// With, so the change applies to them as well.
+func (l *Logger) AddSink(s Sink) SinkID {
+	var id SinkID
+	l.sinks.update(func(list []*sink) []*sink {
+		id = l.sinks.nextID
+		l.sinks.nextID++
+		return append(list, newSink(id, s, nil))
+	})
+	return id
+}
+
+// RemoveSink removes a sink and reports whether it existed. Removing
+// DefaultSink stops the output set by SetOutput until it is set again.
+func (l *Logger) RemoveSink(id SinkID) bool {
+	var found bool
+	l.sinks.update(func(list []*sink) []*sink {
+		for i, s := range list {
+			if s.id == id {
+				found = true
+				return append(list[:i], list[i+1:]...)
+			}
+		}
+		return list
+	})
+	return found
+}
+
+// Flush writes the summaries of pending dedupe intervals, then the entries
+// queued by SetAsync and those buffered by the sinks' writers, such as an
+// AsyncWriter.
+func (l *Logger) Flush() error {
+	l.limiter.flush()
+	if q := l.sinks.async.Load(); q != nil {
+		q.wait()
+	}
+	var errs []error
+	for _, s := range l.sinks.load() {
+		if f, ok := s.w.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
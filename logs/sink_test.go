@@ -0,0 +1,73 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_AddSink(t *testing.T) {
+	var text, errs bytes.Buffer
+	l := newTestLogger(&text)
+	id := l.AddSink(Sink{Level: LevelError, Formatter: &JSONFormatter{}, Writer: &errs})
+
+	child := l.With("component", "sync")
+	child.Info("started")
+	child.Error("failed %d", 1)
+
+	if strings.Count(text.String(), "\n") != 2 {
+		t.Fatalf("default sink got %q", text.String())
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(errs.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", errs.String(), err.Error())
+	}
+	if got["level"] != "error" || got["msg"] != "failed 1" || got["component"] != "sync" {
+		t.Fatalf("unexpected entry %v", got)
+	}
+
+	if !l.RemoveSink(id) || l.RemoveSink(id) {
+		t.Fatalf("expected the sink to be removed once")
+	}
+	errs.Reset()
+	l.Error("again")
+	if errs.Len() != 0 {
+		t.Fatalf("removed sink got %q", errs.String())
+	}
+
+	l.RemoveSink(DefaultSink)
+	text.Reset()
+	l.Error("nowhere")
+	l.SetOutput(&text)
+	l.Error("back")
+	if got := text.String(); strings.Contains(got, "nowhere") || !strings.Contains(got, "back") {
+		t.Fatalf("unexpected default sink output %q", got)
+	}
+}
+
+func Test_AddSink_Concurrent(t *testing.T) {
+	l := newTestLogger(&bytes.Buffer{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Infow("entry", "j", j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.RemoveSink(l.AddSink(Sink{Writer: io.Discard}))
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(l.sinks.load()); n != 1 {
+		t.Fatalf("expected only the default sink, got %d", n)
+	}
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogLevelFatal is the slog level LevelFatal maps to.
+const SlogLevelFatal = slog.LevelError + 4
+
+// SlogLevel maps lvl to the matching slog level.
+func (lvl Level) SlogLevel() slog.Level {
+	switch lvl {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return SlogLevelFatal
+}
+
+// LevelFromSlog maps an slog level to the closest Level at or below it.
+func LevelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	case level < SlogLevelFatal:
+		return LevelError
+	}
+	return LevelFatal
+}
+
+// NewFromHandler returns a Logger that sends every entry to h, which also
+// decides which levels are enabled. Entries carry the Logger's fields and,
+// for Ctx* methods, the context fields and "request_id", "trace_id" and
+// "span_id" attributes; the context is passed on to h.
+func NewFromHandler(h slog.Handler) *Logger {
+	l := New()
+	l.SetLevel(LevelDebug)
+	l.handler = h
+	return l
+}
+
+func (l *Logger) handle(e *Entry) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.handler.Enabled(ctx, e.Level.SlogLevel()) {
+		return
+	}
+	r := slog.NewRecord(e.Time, e.Level.SlogLevel(), e.Message, e.pc)
+	if e.Logger != "" {
+		r.AddAttrs(slog.String("logger", e.Logger))
+	}
+	if e.RequestID != "" {
+		r.AddAttrs(slog.String("request_id", e.RequestID))
+	}
+	if e.TraceID != "" {
+		r.AddAttrs(slog.String("trace_id", e.TraceID), slog.String("span_id", e.SpanID))
+	}
+	for _, f := range e.Fields {
+		r.AddAttrs(slog.Any(f.Key, f.Value))
+	}
+	if e.Stack != "" {
+		r.AddAttrs(slog.String("stack", e.Stack))
+	}
+	_ = l.handler.Handle(ctx, r)
+}
+
+// Handler returns an slog.Handler that writes records through l, so slog
+// call sites share its level, output and formatter. Groups become dotted
+// key prefixes.
+func (l *Logger) Handler() slog.Handler {
+	return &slogHandler{l: l}
+}
+
+type slogHandler struct {
+	l      *Logger
+	prefix string // open groups, each followed by "."
+	fields []Field
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.enabled(LevelFromSlog(level))
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make([]Field, len(h.fields), len(h.fields)+r.NumAttrs())
+	copy(fields, h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, h.prefix, a)
+		return true
+	})
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	level := LevelFromSlog(r.Level)
+	e := h.l.newEntryAt(ctx, t, r.PC, level, h.l.msgPrefix+r.Message, fields)
+	if level >= h.l.stackLevel {
+		e.Stack = captureStackFrom(r.PC, 1)
+	}
+	h.l.write(e)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	child := *h
+	child.fields = h.fields[:len(h.fields):len(h.fields)]
+	for _, a := range attrs {
+		child.fields = appendAttr(child.fields, h.prefix, a)
+	}
+	return &child
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+// appendAttr flattens a into fields following the slog.Handler rules:
+// empty attributes and empty groups are dropped and groups without a key
+// are inlined.
+func appendAttr(fields []Field, prefix string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, Field{Key: prefix + a.Key, Value: a.Value.Any()})
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, ga := range a.Value.Group() {
+		fields = appendAttr(fields, prefix, ga)
+	}
+	return fields
+}
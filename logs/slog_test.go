@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func Test_Handler(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&JSONFormatter{})
+
+	sl := slog.New(l.Handler()).With("a", 1).WithGroup("g")
+	sl.Debug("dropped")
+	sl.Warn("hello", "k", "v", slog.Group("sub", "x", true), slog.Group("empty"))
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", buf.String(), err.Error())
+	}
+	if got["level"] != "warn" || got["msg"] != "hello" || got["a"] != float64(1) || got["g.k"] != "v" || got["g.sub.x"] != true {
+		t.Fatalf("unexpected entry %v", got)
+	}
+	if _, ok := got["g.empty"]; ok {
+		t.Fatalf("empty group was logged: %v", got)
+	}
+	if caller, _ := got["caller"].(string); !strings.HasPrefix(caller, "slog_test.go:") {
+		t.Fatalf("unexpected caller %v", got["caller"])
+	}
+}
+
+func Test_NewFromHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo, AddSource: true})
+	l := NewFromHandler(h).With("component", "sync")
+
+	l.Debug("dropped")
+	l.Error("failed %d times", 3)
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal %q error: %s", buf.String(), err.Error())
+	}
+	if got["level"] != "ERROR" || got["msg"] != "failed 3 times" || got["component"] != "sync" {
+		t.Fatalf("unexpected record %v", got)
+	}
+	source, _ := got["source"].(map[string]interface{})
+	if file, _ := source["file"].(string); !strings.HasSuffix(file, "slog_test.go") {
+		t.Fatalf("unexpected source %v", got["source"])
+	}
+}
+
+func Test_LevelFromSlog(t *testing.T) {
+	for lvl := LevelDebug; lvl <= LevelFatal; lvl++ {
+		if got := LevelFromSlog(lvl.SlogLevel()); got != lvl {
+			t.Fatalf("level %v round-tripped to %v", lvl, got)
+		}
+	}
+	if got := LevelFromSlog(slog.LevelInfo + 2); got != LevelInfo {
+		t.Fatalf("got %v, want INFO", got)
+	}
+}
+
+func Test_HandlerStackTrace(t *testing.T) {
+	var buf bytes.Buffer
+	l := newTestLogger(&buf)
+	l.SetFormatter(&JSONFormatter{})
+	l.SetStackTraceLevel(LevelError)
+
+	sl := slog.New(l.Handler())
+	sl.Warn("no stack")
+	sl.Error("with stack")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+	var warn, failure map[string]interface{}
+	json.Unmarshal([]byte(lines[0]), &warn)
+	json.Unmarshal([]byte(lines[1]), &failure)
+	if _, ok := warn["stack"]; ok {
+		t.Fatalf("warn entry has a stack: %v", warn)
+	}
+	stack, _ := failure["stack"].(string)
+	if !strings.HasPrefix(stack, "github.com/f0resee/stdlib/logs.Test_HandlerStackTrace\n\t") {
+		t.Fatalf("unexpected stack %q", stack)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 )
 
 type IClient interface {
@@ -31,7 +32,32 @@ func NewRESTClient(baseURL *url.URL, client *http.Client) (*RESTClient, error) {
 type RESTClient struct {
 	base *url.URL
 
+	mu         sync.Mutex
+	closed     bool
+	inflight   int
+	drained    chan struct{}
+	closedCh   chan struct{} // closed by Close, see closedChan
+	websockets map[*WebSocketConn]struct{}
+
 	Client *http.Client
+
+	// TimingsObserver, when set, receives the timings of every request.
+	TimingsObserver TimingsObserver
+
+	// Metrics, when set, observes every Request.Do call.
+	Metrics Metrics
+
+	// Signer, when set, adds authentication headers to every request.
+	Signer Signer
+
+	// RequestIDHeader names the header carrying the request ID, defaulting
+	// to DefaultRequestIDHeader.
+	RequestIDHeader string
+
+	// Verbosity controls request/response logging through the logs package:
+	// 1 logs method, URL and status, 2 adds redacted headers and 3 adds
+	// truncated bodies.
+	Verbosity int
 }
 
 func (c *RESTClient) Verb(verb string) *Request {
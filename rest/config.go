@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+const unixScheme = "unix"
+
+// Config describes how to reach a server. Host is either an http(s) URL,
+// optionally with a base path, or unix:///path/to.sock for a server
+// listening on a unix domain socket.
+type Config struct {
+	Host string
+
+	Timeout time.Duration
+
+	// H2C forces cleartext HTTP/2 with prior knowledge. It cannot be combined
+	// with an https Host.
+	H2C bool
+
+	// Transport, when set, is used as is and H2C and unix sockets are left to
+	// it.
+	Transport http.RoundTripper
+
+	// Jar stores cookies across requests, see cookiejar.New for an
+	// in-memory jar and NewFileCookieJar for one persisted to disk.
+	Jar http.CookieJar
+}
+
+// RESTClientFor builds a RESTClient and its http.Client from config.
+func RESTClientFor(config *Config) (*RESTClient, error) {
+	baseURL, socketPath, err := parseHost(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	if config.H2C && baseURL.Scheme == "https" {
+		return nil, fmt.Errorf("h2c requires an http host, got %q", config.Host)
+	}
+
+	transport := config.Transport
+	if transport == nil {
+		transport = newTransport(config, socketPath)
+	}
+	return NewRESTClient(baseURL, &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout,
+		Jar:       config.Jar,
+	})
+}
+
+// parseHost returns the base URL requests are built from and, for unix
+// hosts, the socket path to dial.
+func parseHost(host string) (*url.URL, string, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, "", err
+	}
+	switch hostURL.Scheme {
+	case "http", "https":
+		if hostURL.Host == "" {
+			return nil, "", fmt.Errorf("host %q has no address", host)
+		}
+		return hostURL, "", nil
+	case unixScheme:
+		if hostURL.Path == "" {
+			return nil, "", fmt.Errorf("host %q has no socket path", host)
+		}
+		// The address is ignored by the dialer and only ends up in the Host
+		// header.
+		return &url.URL{Scheme: "http", Host: "localhost"}, hostURL.Path, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported scheme %q in host %q", hostURL.Scheme, host)
+	}
+}
+
+func newTransport(config *Config, socketPath string) http.RoundTripper {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	dial := dialer.DialContext
+	if socketPath != "" {
+		dial = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, unixScheme, socketPath)
+		}
+	}
+
+	if config.H2C {
+		return &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dial(ctx, network, addr)
+			},
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dial
+	if socketPath != "" {
+		transport.Proxy = nil
+	}
+	return transport
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+}
+
+func Test_UnixSocketTransport(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "rest.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("listen unix error: %s", err.Error())
+	}
+	// h2c.NewHandler still serves plain HTTP/1.1 requests.
+	server := &http.Server{Handler: h2c.NewHandler(protoHandler(), &http2.Server{})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	for _, config := range []*Config{
+		{Host: "unix://" + socketPath},
+		{Host: "unix://" + socketPath, H2C: true},
+	} {
+		restClient, err := RESTClientFor(config)
+		if err != nil {
+			t.Fatalf("rest client for %+v error: %s", config, err.Error())
+		}
+		result := restClient.Get().AbsPath("/proto").Do(context.Background())
+		if result.err != nil {
+			t.Fatalf("do error: %s", result.err.Error())
+		}
+		want := "HTTP/1.1"
+		if config.H2C {
+			want = "HTTP/2.0"
+		}
+		if string(result.body) != want {
+			t.Fatalf("proto = %q, want %q", result.body, want)
+		}
+	}
+}
+
+func Test_H2CTransport(t *testing.T) {
+	server := httptest.NewServer(h2c.NewHandler(protoHandler(), &http2.Server{}))
+	defer server.Close()
+
+	restClient, err := RESTClientFor(&Config{Host: server.URL, H2C: true})
+	if err != nil {
+		t.Fatalf("rest client error: %s", err.Error())
+	}
+	result := restClient.Get().AbsPath("/proto").Do(context.Background())
+	if result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	if string(result.body) != "HTTP/2.0" {
+		t.Fatalf("proto = %q, want HTTP/2.0", result.body)
+	}
+
+	if _, err := RESTClientFor(&Config{Host: "https://127.0.0.1", H2C: true}); err == nil {
+		t.Fatalf("expected h2c with https host to fail")
+	}
+}
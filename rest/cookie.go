@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileCookieJar is an http.CookieJar persisted as JSON to a file. Cookies
+// are written back after every response that changes them.
+type FileCookieJar struct {
+	jar      *cookiejar.Jar
+	filename string
+
+	// OnSaveError, when set, receives the errors of the saves done by
+	// SetCookies, which cannot return them.
+	OnSaveError func(error)
+
+	// saveMu serializes whole saves so an older snapshot never replaces a
+	// newer one; mu guards entries.
+	saveMu  sync.Mutex
+	mu      sync.Mutex
+	entries map[string]persistedCookie
+	version uint64 // bumped on every change to entries
+	saved   uint64 // version last written to the file
+}
+
+type persistedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// NewFileCookieJar loads the cookies stored in filename, if it exists.
+func NewFileCookieJar(filename string) (*FileCookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	j := &FileCookieJar{
+		jar:      jar,
+		filename: filename,
+		entries:  make(map[string]persistedCookie),
+	}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []persistedCookie
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, entry := range entries {
+		u, err := url.Parse(entry.URL)
+		if err != nil || entry.Cookie == nil {
+			continue
+		}
+		if !entry.Cookie.Expires.IsZero() && entry.Cookie.Expires.Before(now) {
+			continue
+		}
+		j.jar.SetCookies(u, []*http.Cookie{entry.Cookie})
+		j.entries[cookieKey(u, entry.Cookie)] = entry
+	}
+	return j, nil
+}
+
+func (j *FileCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar. Errors writing the file are passed
+// to OnSaveError.
+func (j *FileCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	now := time.Now()
+	for _, cookie := range cookies {
+		key := cookieKey(u, cookie)
+		if cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(now)) {
+			if _, ok := j.entries[key]; ok {
+				delete(j.entries, key)
+				j.version++
+			}
+			continue
+		}
+		stored := *cookie
+		if cookie.MaxAge > 0 {
+			stored.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+			stored.MaxAge = 0
+		}
+		j.entries[key] = persistedCookie{URL: u.Scheme + "://" + u.Host + "/", Cookie: &stored}
+		j.version++
+	}
+	version := j.version
+	j.mu.Unlock()
+
+	if err := j.save(version, false); err != nil && j.OnSaveError != nil {
+		j.OnSaveError(err)
+	}
+}
+
+// Save writes the cookies to the file, replacing it atomically.
+func (j *FileCookieJar) Save() error {
+	return j.save(0, true)
+}
+
+// save writes the current cookies. Unless forced, it skips the write when
+// a save that already included version ran while waiting for saveMu.
+func (j *FileCookieJar) save(version uint64, force bool) error {
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+
+	j.mu.Lock()
+	if !force && version <= j.saved {
+		j.mu.Unlock()
+		return nil
+	}
+	current := j.version
+	entries := make([]persistedCookie, 0, len(j.entries))
+	for _, entry := range j.entries {
+		entries = append(entries, entry)
+	}
+	j.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(j.filename), filepath.Base(j.filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.filename); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	j.mu.Lock()
+	j.saved = current
+	j.mu.Unlock()
+	return nil
+}
+
+func cookieKey(u *url.URL, cookie *http.Cookie) string {
+	domain := cookie.Domain
+	if domain == "" {
+		domain = u.Hostname()
+	}
+	return domain + ";" + cookie.Path + ";" + cookie.Name
+}
@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_FileCookieJarConcurrentSaves(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewFileCookieJar(filename)
+	if err != nil {
+		t.Fatalf("new file cookie jar error: %s", err.Error())
+	}
+	jar.OnSaveError = func(err error) {
+		t.Errorf("save error: %s", err.Error())
+	}
+
+	u, _ := url.Parse("http://example.com/")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			jar.SetCookies(u, []*http.Cookie{{Name: "c" + strconv.Itoa(i), Value: "v", MaxAge: 3600}})
+		}(i)
+	}
+	wg.Wait()
+
+	reloaded, err := NewFileCookieJar(filename)
+	if err != nil {
+		t.Fatalf("reload file cookie jar error: %s", err.Error())
+	}
+	if got := len(reloaded.Cookies(u)); got != 20 {
+		t.Fatalf("reloaded %d cookies, want 20", got)
+	}
+}
+
+func Test_FileCookieJarSaveError(t *testing.T) {
+	dir := t.TempDir()
+	jar, err := NewFileCookieJar(filepath.Join(dir, "missing", "cookies.json"))
+	if err != nil {
+		t.Fatalf("new file cookie jar error: %s", err.Error())
+	}
+	var saveErr error
+	jar.OnSaveError = func(err error) {
+		saveErr = err
+	}
+
+	u, _ := url.Parse("http://example.com/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "1"}})
+	if !errors.Is(saveErr, os.ErrNotExist) {
+		t.Fatalf("OnSaveError got %v, want a not exist error", saveErr)
+	}
+	if err := jar.Save(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Save() = %v, want a not exist error", err)
+	}
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrStreamedBody is returned by CurlCommand for a request whose body is an
+// io.Reader, which cannot be rendered.
+var ErrStreamedBody = errors.New("rest: streamed request body cannot be rendered")
+
+// CurlCommand renders the request as a curl command line. After Do it
+// renders the last attempt exactly as sent, including the request ID, trace
+// context and Signer headers. Before Do it renders the request unsigned and
+// without the request ID and trace context Do adds, and leaves r unchanged.
+// Header values are not redacted.
+func (r *Request) CurlCommand() (string, error) {
+	if r.body != nil {
+		return "", ErrStreamedBody
+	}
+	req := r.sent
+	if req == nil {
+		if r.err != nil {
+			return "", r.err
+		}
+		var err error
+		req, err = r.buildHTTPRequest(context.Background())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	args := []string{"curl", "-X", shellQuote(req.Method)}
+
+	header := req.Header.Clone()
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			args = append(args, "-H", shellQuote(key+": "+value))
+		}
+	}
+
+	if r.bodyBytes != nil {
+		args = append(args, "--data-binary", shellQuote(string(r.bodyBytes)))
+	}
+
+	if r.timeout > 0 {
+		args = append(args, "--max-time", strconv.FormatFloat(r.timeout.Seconds(), 'f', -1, 64))
+	}
+
+	args = append(args, shellQuote(req.URL.String()))
+	return strings.Join(args, " "), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
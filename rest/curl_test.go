@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+func Test_CurlCommand(t *testing.T) {
+	hostURL, _ := url.Parse("http://127.0.0.1:8000/api")
+	restClient, err := NewRESTClient(hostURL, nil)
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	cmd, err := restClient.Post().AbsPath("items").Param("q", "a b").
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Authorization", "Bearer token").
+		SetHeader(DefaultRequestIDHeader, "req-1").
+		Body([]byte(`{"name":"it's"}`)).
+		Timeout(1500 * time.Millisecond).
+		CurlCommand()
+	if err != nil {
+		t.Fatalf("curl command error: %s", err.Error())
+	}
+
+	want := `curl -X 'POST' -H 'Authorization: Bearer token' -H 'Content-Type: application/json' -H 'X-Request-Id: req-1' ` +
+		`--data-binary '{"name":"it'\''s"}' --max-time 1.5 'http://127.0.0.1:8000/api/items/?q=a+b'`
+	if cmd != want {
+		t.Fatalf("curl command mismatch:\n got: %s\nwant: %s", cmd, want)
+	}
+}
+
+func Test_CurlCommandAfterDo(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	restClient.Signer = SignerFunc(func(req *SignableRequest) (http.Header, error) {
+		return http.Header{"X-Signature": {"sig"}}, nil
+	})
+
+	ctx := context.WithValue(context.Background(), logs.TraceParent{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req := restClient.Get().AbsPath("/items")
+	if result := req.Do(ctx); result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	cmd, err := req.CurlCommand()
+	if err != nil {
+		t.Fatalf("curl command error: %s", err.Error())
+	}
+	for _, key := range []string{DefaultRequestIDHeader, "Traceparent", "X-Signature"} {
+		want := shellQuote(http.CanonicalHeaderKey(key) + ": " + got.Get(key))
+		if got.Get(key) == "" || !strings.Contains(cmd, want) {
+			t.Fatalf("curl command missing %s:\n%s", want, cmd)
+		}
+	}
+}
+
+func Test_CurlCommandBeforeDo(t *testing.T) {
+	hostURL, _ := url.Parse("http://127.0.0.1:8000")
+	restClient, err := NewRESTClient(hostURL, nil)
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	var signed int
+	restClient.Signer = SignerFunc(func(req *SignableRequest) (http.Header, error) {
+		signed++
+		return http.Header{"X-Signature": {"sig"}}, nil
+	})
+
+	req := restClient.Get().AbsPath("/items")
+	cmd, err := req.CurlCommand()
+	if err != nil {
+		t.Fatalf("curl command error: %s", err.Error())
+	}
+	if want := `curl -X 'GET' 'http://127.0.0.1:8000/items/'`; cmd != want {
+		t.Fatalf("curl command mismatch:\n got: %s\nwant: %s", cmd, want)
+	}
+	if signed != 0 || len(req.headers) != 0 {
+		t.Fatalf("preview signed %d times and set headers %v", signed, req.headers)
+	}
+
+	if _, err := restClient.Put().AbsPath("/items").Body(strings.NewReader("{}")).CurlCommand(); err != ErrStreamedBody {
+		t.Fatalf("streamed body error = %v, want ErrStreamedBody", err)
+	}
+}
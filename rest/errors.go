@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// StatusError is returned in Result when the server answers with a status
+// other than 200 OK. Body holds the response body.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status not ok: %d", e.StatusCode)
+}
+
+type ErrorClass string
+
+const (
+	ErrorClassNone        ErrorClass = ""
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassCanceled    ErrorClass = "canceled"
+	ErrorClassNetwork     ErrorClass = "network"
+	ErrorClassClientError ErrorClass = "client_error"
+	ErrorClassServerError ErrorClass = "server_error"
+	ErrorClassOther       ErrorClass = "other"
+)
+
+// ClassifyError maps an error returned in Result to a low-cardinality class.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode >= 500 {
+			return ErrorClassServerError
+		}
+		return ErrorClassClientError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
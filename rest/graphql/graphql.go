@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+const persistedQueryNotFound = "PersistedQueryNotFound"
+
+// Client posts GraphQL operations to a single endpoint through a
+// rest.RESTClient.
+type Client struct {
+	client *rest.RESTClient
+	path   string
+
+	// PersistedQueries sends only the SHA-256 hash of the query first, and
+	// the full query when the server does not know the hash yet.
+	PersistedQueries bool
+}
+
+func NewClient(client *rest.RESTClient, path string) *Client {
+	return &Client{
+		client: client,
+		path:   path,
+	}
+}
+
+type Request struct {
+	Query         string
+	OperationName string
+	Variables     map[string]interface{}
+}
+
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is one entry of the response "errors" array. Path elements are
+// field names (string) or list indexes (float64).
+type Error struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return fmt.Sprintf("graphql: %s (path: %s)", e.Message, strings.Join(path, "."))
+}
+
+// Errors is returned when the response carries errors. The data that could
+// be resolved is still decoded into the result.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+type payload struct {
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors Errors          `json:"errors"`
+}
+
+// Query runs query with variables and decodes "data" into result.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
+	return c.Do(ctx, &Request{Query: query, Variables: variables}, result)
+}
+
+func (c *Client) Do(ctx context.Context, req *Request, result interface{}) error {
+	body := payload{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+	}
+
+	if c.PersistedQueries {
+		sum := sha256.Sum256([]byte(req.Query))
+		body.Extensions = map[string]interface{}{
+			"persistedQuery": map[string]interface{}{
+				"version":    1,
+				"sha256Hash": hex.EncodeToString(sum[:]),
+			},
+		}
+		hashOnly := body
+		hashOnly.Query = ""
+		resp, err := c.post(ctx, hashOnly)
+		if err != nil {
+			return err
+		}
+		if !resp.Errors.persistedQueryNotFound() {
+			return decode(resp, result)
+		}
+	}
+
+	resp, err := c.post(ctx, body)
+	if err != nil {
+		return err
+	}
+	return decode(resp, result)
+}
+
+func (c *Client) post(ctx context.Context, body payload) (*response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.client.Post().
+		AbsPath().Suffix(c.path).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
+		Body(data).
+		Do(ctx).
+		Raw()
+
+	var resp response
+	if err != nil {
+		// GraphQL servers may reject a request with an HTTP error status
+		// and still describe the problem in "errors".
+		var statusErr *rest.StatusError
+		if errors.As(err, &statusErr) && json.Unmarshal(raw, &resp) == nil && len(resp.Errors) > 0 {
+			return &resp, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("graphql: decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+func decode(resp *response, result interface{}) error {
+	if result != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			return fmt.Errorf("graphql: decode data: %w", err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
+
+func (e Errors) persistedQueryNotFound() bool {
+	for _, err := range e {
+		if err.Message == persistedQueryNotFound || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+func Test_Client(t *testing.T) {
+	known := map[string]string{}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var body payload
+		json.NewDecoder(r.Body).Decode(&body)
+
+		query := body.Query
+		if persisted, ok := body.Extensions["persistedQuery"].(map[string]interface{}); ok {
+			hash := persisted["sha256Hash"].(string)
+			if query == "" {
+				query, ok = known[hash]
+				if !ok {
+					w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))
+					return
+				}
+			}
+			known[hash] = query
+		}
+
+		switch query {
+		case "query($id: ID!) { user(id: $id) { name } }":
+			name := body.Variables["id"].(string) + "-name"
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"user": map[string]string{"name": name}}})
+		default:
+			w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"not found","path":["user",0],"locations":[{"line":1,"column":3}]}]}`))
+		}
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := rest.NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	client := NewClient(restClient, "/graphql")
+	ctx := context.Background()
+
+	var result struct {
+		User *struct{ Name string } `json:"user"`
+	}
+	query := "query($id: ID!) { user(id: $id) { name } }"
+	if err := client.Query(ctx, query, map[string]interface{}{"id": "1"}, &result); err != nil {
+		t.Fatalf("query error: %s", err.Error())
+	}
+	if result.User == nil || result.User.Name != "1-name" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	err = client.Query(ctx, "{ broken }", nil, &result)
+	var gqlErrs Errors
+	if !errors.As(err, &gqlErrs) || len(gqlErrs) != 1 {
+		t.Fatalf("expected graphql errors, got %v", err)
+	}
+	if gqlErrs[0].Locations[0].Line != 1 || gqlErrs[0].Path[0] != "user" || gqlErrs[0].Path[1] != float64(0) {
+		t.Fatalf("unexpected error details: %+v", gqlErrs[0])
+	}
+
+	client.PersistedQueries = true
+	requests = 0
+	for i := 0; i < 2; i++ {
+		if err := client.Query(ctx, query, map[string]interface{}{"id": "2"}, &result); err != nil {
+			t.Fatalf("persisted query error: %s", err.Error())
+		}
+	}
+	// The first call registers the query, the second sends the hash only.
+	if requests != 3 || result.User.Name != "2-name" {
+		t.Fatalf("requests = %d, result = %+v", requests, result)
+	}
+}
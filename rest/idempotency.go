@@ -0,0 +1,209 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader marks responses replayed by
+	// IdempotencyMiddleware.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyMaxBodyBytes = 1 << 20
+)
+
+// Idempotent attaches a unique Idempotency-Key header to the request. The
+// key stays the same across retries and repeated Do calls, and allows POST
+// and PATCH requests to be retried.
+func (r *Request) Idempotent() *Request {
+	if r.err != nil {
+		return r
+	}
+	if r.idempotencyKey == "" {
+		r.idempotencyKey = NewRequestID()
+	}
+	r.SetHeader(IdempotencyKeyHeader, r.idempotencyKey)
+	return r
+}
+
+type idempotentResponse struct {
+	done     chan struct{}
+	expires  time.Time
+	bodyHash [sha256.Size]byte
+
+	status int
+	header http.Header
+	body   []byte
+}
+
+type idempotencyCache struct {
+	ttl          time.Duration
+	maxBodyBytes int64
+	next         http.Handler
+
+	mu        sync.Mutex
+	entries   map[string]*idempotentResponse
+	lastSweep time.Time
+}
+
+// IdempotencyConfig configures IdempotencyMiddlewareWithConfig.
+type IdempotencyConfig struct {
+	TTL time.Duration
+
+	// MaxBodyBytes bounds the body of requests with an Idempotency-Key,
+	// which is read into memory to be compared. Larger bodies are answered
+	// with 413. It defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+// IdempotencyMiddleware dedupes requests carrying an Idempotency-Key for
+// ttl. The first request with a key runs; duplicates wait for it and get
+// its response replayed. Reusing a key with a different body is answered
+// with 422. 5xx responses and handler panics are not kept, so a retry after
+// a server failure runs again.
+func IdempotencyMiddleware(ttl time.Duration) func(http.Handler) http.Handler {
+	return IdempotencyMiddlewareWithConfig(IdempotencyConfig{TTL: ttl})
+}
+
+// IdempotencyMiddlewareWithConfig is IdempotencyMiddleware with a
+// configurable request body limit.
+func IdempotencyMiddlewareWithConfig(config IdempotencyConfig) func(http.Handler) http.Handler {
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultIdempotencyMaxBodyBytes
+	}
+	return func(next http.Handler) http.Handler {
+		return &idempotencyCache{
+			ttl:          config.TTL,
+			maxBodyBytes: config.MaxBodyBytes,
+			next:         next,
+			entries:      make(map[string]*idempotentResponse),
+		}
+	}
+}
+
+func (c *idempotencyCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		c.next.ServeHTTP(w, r)
+		return
+	}
+	key = r.Method + " " + r.URL.Path + " " + key
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, c.maxBodyBytes))
+	r.Body.Close()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "reading request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	bodyHash := sha256.Sum256(body)
+
+	for {
+		c.mu.Lock()
+		c.sweep()
+		entry, ok := c.entries[key]
+		if !ok {
+			entry = &idempotentResponse{done: make(chan struct{}), bodyHash: bodyHash}
+			c.entries[key] = entry
+			c.mu.Unlock()
+			c.record(w, r, key, entry)
+			return
+		}
+		c.mu.Unlock()
+		if entry.bodyHash != bodyHash {
+			http.Error(w, "idempotency key reused with a different request body", http.StatusUnprocessableEntity)
+			return
+		}
+
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			return
+		}
+		c.mu.Lock()
+		_, kept := c.entries[key]
+		c.mu.Unlock()
+		if !kept {
+			// The first attempt failed and was dropped; run this one.
+			continue
+		}
+		for k, v := range entry.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+		return
+	}
+}
+
+func (c *idempotencyCache) record(w http.ResponseWriter, r *http.Request, key string, entry *idempotentResponse) {
+	recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+	completed := false
+	defer func() {
+		c.mu.Lock()
+		// A panicking handler leaves the default status behind; keep
+		// nothing so a retry runs again.
+		if !completed || recorder.status >= http.StatusInternalServerError {
+			delete(c.entries, key)
+		} else {
+			entry.status = recorder.status
+			entry.header = w.Header().Clone()
+			entry.body = recorder.body.Bytes()
+			entry.expires = time.Now().Add(c.ttl)
+		}
+		c.mu.Unlock()
+		close(entry.done)
+	}()
+	c.next.ServeHTTP(recorder, r)
+	completed = true
+}
+
+// sweep drops expired entries at most once per ttl. It must be called with
+// c.mu held.
+func (c *idempotencyCache) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
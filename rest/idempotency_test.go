@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/f0resee/stdlib/logs"
+	"github.com/f0resee/stdlib/logs/logtest"
+)
+
+func Test_IdempotentRetry(t *testing.T) {
+	var attempts, created atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		created.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("order-1"))
+	})
+	server := httptest.NewServer(IdempotencyMiddleware(time.Minute)(handler))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+
+	plain := restClient.Post().AbsPath("/orders").Body([]byte("{}")).MaxRetries(2).RetryInterval(time.Millisecond).Do(context.Background())
+	if plain.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("POST without idempotency key was retried: %d", plain.StatusCode())
+	}
+
+	req := restClient.Post().AbsPath("/orders").Body([]byte("{}")).MaxRetries(2).RetryInterval(time.Millisecond).Idempotent()
+	result := req.Do(context.Background())
+	if result.StatusCode() != http.StatusCreated || string(result.body) != "order-1" {
+		t.Fatalf("idempotent POST failed: %d %v", result.StatusCode(), result.Error())
+	}
+
+	// Sending the same request again replays the stored response.
+	replay := req.Do(context.Background())
+	if replay.StatusCode() != http.StatusCreated || replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("duplicate request was not replayed: %d %v", replay.StatusCode(), replay.Header())
+	}
+	if created.Load() != 1 {
+		t.Fatalf("handler created %d orders, want 1", created.Load())
+	}
+}
+
+func Test_IdempotencyKeyReusedWithDifferentBody(t *testing.T) {
+	var created atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		created.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	})
+	middleware := IdempotencyMiddleware(time.Minute)(handler)
+
+	send := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	if code := send(`{"n":1}`); code != http.StatusCreated {
+		t.Fatalf("first request status = %d", code)
+	}
+	if code := send(`{"n":1}`); code != http.StatusCreated {
+		t.Fatalf("replayed request status = %d", code)
+	}
+	if code := send(`{"n":2}`); code != http.StatusUnprocessableEntity {
+		t.Fatalf("request with a different body status = %d, want 422", code)
+	}
+	if created.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", created.Load())
+	}
+}
+
+func Test_IdempotencyPanicNotStored(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	middleware := IdempotencyMiddleware(time.Minute)(handler)
+
+	send := func() (code int) {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{}"))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		recorder := httptest.NewRecorder()
+		defer func() {
+			if recover() != nil {
+				code = -1
+			}
+		}()
+		middleware.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	if code := send(); code != -1 {
+		t.Fatalf("panicking handler did not panic, status %d", code)
+	}
+	if code := send(); code != http.StatusCreated || calls.Load() != 2 {
+		t.Fatalf("retry after panic = %d after %d calls, want a fresh 201", code, calls.Load())
+	}
+}
+
+func Test_IdempotencyKeyLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+	rec := &logtest.Recorder{}
+	id := logs.Default().AddSink(rec.Sink())
+	defer logs.Default().RemoveSink(id)
+
+	req := restClient.Post().AbsPath().Suffix("/orders").Body([]byte("{}")).Idempotent()
+	req.Do(context.Background())
+	if n := rec.FilterMessageContains("rest ").Len(); n != 0 {
+		t.Fatalf("logged %d lines at VerbosityNone", n)
+	}
+
+	restClient.Verbosity = VerbosityStatus
+	req.Do(context.Background())
+	requests := rec.FilterMessageContains("rest request").All()
+	want := "rest request: POST " + server.URL + "/orders idempotency key " + req.idempotencyKey
+	if len(requests) != 1 || requests[0].Message != want {
+		t.Fatalf("request lines = %+v, want one %q", requests, want)
+	}
+}
+
+func Test_IdempotencyBodyLimit(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+	middleware := IdempotencyMiddlewareWithConfig(IdempotencyConfig{TTL: time.Minute, MaxBodyBytes: 8})(handler)
+
+	send := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, "key-"+body)
+		recorder := httptest.NewRecorder()
+		middleware.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	if code := send("12345678"); code != http.StatusOK {
+		t.Fatalf("body at the limit status = %d", code)
+	}
+	if code := send("123456789"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body over the limit status = %d, want 413", code)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls.Load())
+	}
+}
@@ -0,0 +1,207 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+const version = "2.0"
+
+// Client is a JSON-RPC 2.0 client that posts to a single endpoint through a
+// rest.RESTClient, so request IDs, metrics, logging and transport settings
+// of the underlying client apply to every call.
+type Client struct {
+	client *rest.RESTClient
+	path   string
+	nextID atomic.Uint64
+
+	// MaxRetries is passed to every underlying rest.Request. When set, calls
+	// carry an Idempotency-Key so the POST can be retried safely.
+	MaxRetries int
+}
+
+func NewClient(client *rest.RESTClient, path string) *Client {
+	return &Client{
+		client: client,
+		path:   path,
+	}
+}
+
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+type request struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  interface{}     `json:"params,omitempty"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// BatchElem is one call of a batch. Result receives the decoded result and
+// Error the per-call error, usually an *RPCError. Notification elements
+// are sent without an ID and get no response.
+type BatchElem struct {
+	Method       string
+	Params       interface{}
+	Result       interface{}
+	Notification bool
+	Error        error
+}
+
+// Call invokes method with params and decodes the result into result,
+// which may be nil to discard it.
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	id := c.newID()
+	var resp response
+	if err := c.post(ctx, request{Version: version, ID: id, Method: method, Params: params}, &resp); err != nil {
+		return err
+	}
+	// Servers that cannot read the request id answer with a null id.
+	nullID := len(resp.ID) == 0 || string(resp.ID) == "null"
+	if resp.Error != nil && (nullID || string(resp.ID) == string(id)) {
+		return resp.Error
+	}
+	if string(resp.ID) != string(id) {
+		return fmt.Errorf("jsonrpc: response id %s does not match request id %s", resp.ID, id)
+	}
+	return decodeResult(resp, result)
+}
+
+// Notify sends a notification, for which the server sends no response.
+func (c *Client) Notify(ctx context.Context, method string, params interface{}) error {
+	return c.post(ctx, request{Version: version, Method: method, Params: params}, nil)
+}
+
+// BatchCall sends all elements in one HTTP request and correlates the
+// responses by ID. The returned error covers the transport only; per-call
+// failures are reported in each element's Error.
+func (c *Client) BatchCall(ctx context.Context, batch []BatchElem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	requests := make([]request, len(batch))
+	byID := make(map[string]int, len(batch))
+	for i := range batch {
+		requests[i] = request{Version: version, Method: batch[i].Method, Params: batch[i].Params}
+		if batch[i].Notification {
+			continue
+		}
+		requests[i].ID = c.newID()
+		byID[string(requests[i].ID)] = i
+	}
+
+	var responses []response
+	if len(byID) == 0 {
+		return c.post(ctx, requests, nil)
+	}
+	if err := c.post(ctx, requests, &responses); err != nil {
+		return err
+	}
+
+	for _, resp := range responses {
+		i, ok := byID[string(resp.ID)]
+		if !ok {
+			continue
+		}
+		delete(byID, string(resp.ID))
+		batch[i].Error = decodeResult(resp, batch[i].Result)
+	}
+	for id, i := range byID {
+		batch[i].Error = fmt.Errorf("jsonrpc: no response for request id %s", id)
+	}
+	return nil
+}
+
+func (c *Client) newID() json.RawMessage {
+	return json.RawMessage(strconv.FormatUint(c.nextID.Add(1), 10))
+}
+
+// post sends body and decodes the response into out. A nil out means no
+// response is expected.
+func (c *Client) post(ctx context.Context, body interface{}, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req := c.client.Post().
+		AbsPath().Suffix(c.path).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
+		MaxRetries(c.MaxRetries).
+		Body(data)
+	if c.MaxRetries > 0 {
+		req.Idempotent()
+	}
+	result := req.Do(ctx)
+
+	raw, err := result.Raw()
+	// rest.Request only treats 200 OK as success, while servers may answer
+	// notifications with 202 or 204.
+	var statusErr *rest.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode >= 200 && statusErr.StatusCode < 300 {
+		err = nil
+	}
+	if out == nil {
+		return err
+	}
+	if err != nil {
+		// Servers may answer a failed call with an HTTP error status and
+		// a JSON-RPC error body; prefer the structured error then.
+		if errors.As(err, &statusErr) {
+			var resp response
+			if json.Unmarshal(raw, &resp) == nil && resp.Error != nil {
+				return resp.Error
+			}
+		}
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		// A single error response instead of an array is how servers reject
+		// a malformed batch.
+		var resp response
+		if json.Unmarshal(raw, &resp) == nil && resp.Error != nil {
+			return resp.Error
+		}
+		return fmt.Errorf("jsonrpc: decode response: %w", err)
+	}
+	return nil
+}
+
+func decodeResult(resp response, result interface{}) error {
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil || len(resp.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
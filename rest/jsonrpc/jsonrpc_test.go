@@ -0,0 +1,175 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/f0resee/stdlib/rest"
+)
+
+func rpcServer(t *testing.T, notified *[]string) *httptest.Server {
+	handle := func(req request) *response {
+		if len(req.ID) == 0 {
+			*notified = append(*notified, req.Method)
+			return nil
+		}
+		resp := &response{Version: version, ID: req.ID}
+		switch req.Method {
+		case "add":
+			var params []int
+			json.Unmarshal(req.Params.(json.RawMessage), &params)
+			resp.Result, _ = json.Marshal(params[0] + params[1])
+		default:
+			resp.Error = &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+		}
+		return resp
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rpc" || r.Header.Get(rest.DefaultRequestIDHeader) == "" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		type rawRequest struct {
+			request
+			Params json.RawMessage `json:"params"`
+		}
+		toRequest := func(raw rawRequest) request {
+			req := raw.request
+			req.Params = raw.Params
+			return req
+		}
+
+		if body[0] == '[' {
+			var raws []rawRequest
+			json.Unmarshal(body, &raws)
+			var responses []*response
+			// Answer in reverse order to exercise ID correlation.
+			for i := len(raws) - 1; i >= 0; i-- {
+				if resp := handle(toRequest(raws[i])); resp != nil {
+					responses = append(responses, resp)
+				}
+			}
+			if len(responses) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			json.NewEncoder(w).Encode(responses)
+			return
+		}
+
+		var raw rawRequest
+		json.Unmarshal(body, &raw)
+		resp := handle(toRequest(raw))
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func Test_Client(t *testing.T) {
+	var notified []string
+	server := rpcServer(t, &notified)
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := rest.NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	client := NewClient(restClient, "/rpc")
+	ctx := context.Background()
+
+	var sum int
+	if err := client.Call(ctx, "add", []int{1, 2}, &sum); err != nil || sum != 3 {
+		t.Fatalf("add = %d, %v", sum, err)
+	}
+
+	var rpcErr *RPCError
+	if err := client.Call(ctx, "missing", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatalf("expected method not found, got %v", err)
+	}
+
+	if err := client.Notify(ctx, "ping", nil); err != nil {
+		t.Fatalf("notify error: %s", err.Error())
+	}
+
+	var a, b int
+	batch := []BatchElem{
+		{Method: "add", Params: []int{1, 1}, Result: &a},
+		{Method: "missing"},
+		{Method: "ping", Notification: true},
+		{Method: "add", Params: []int{2, 3}, Result: &b},
+	}
+	if err := client.BatchCall(ctx, batch); err != nil {
+		t.Fatalf("batch error: %s", err.Error())
+	}
+	if a != 2 || b != 5 || batch[0].Error != nil || batch[3].Error != nil {
+		t.Fatalf("unexpected batch results: a=%d b=%d %+v", a, b, batch)
+	}
+	if !errors.As(batch[1].Error, &rpcErr) {
+		t.Fatalf("expected rpc error for batch element, got %v", batch[1].Error)
+	}
+	if len(notified) != 2 {
+		t.Fatalf("notifications = %v, want two pings", notified)
+	}
+}
+
+func Test_NotifyStatus(t *testing.T) {
+	var status int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := rest.NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	client := NewClient(restClient, "/rpc")
+	for _, status = range []int{http.StatusOK, http.StatusAccepted, http.StatusNoContent} {
+		if err := client.Notify(context.Background(), "ping", nil); err != nil {
+			t.Fatalf("notify with status %d error: %s", status, err.Error())
+		}
+	}
+	status = http.StatusInternalServerError
+	var statusErr *rest.StatusError
+	if err := client.Notify(context.Background(), "ping", nil); !errors.As(err, &statusErr) {
+		t.Fatalf("notify with status 500 = %v, want *rest.StatusError", err)
+	}
+}
+
+func Test_CallNullIDError(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := rest.NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	client := NewClient(restClient, "/rpc")
+
+	body = `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`
+	var rpcErr *RPCError
+	if err := client.Call(context.Background(), "add", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != CodeParseError {
+		t.Fatalf("call = %v, want the parse error", err)
+	}
+
+	body = `{"jsonrpc":"2.0","id":99,"error":{"code":-32603,"message":"internal error"}}`
+	if err := client.Call(context.Background(), "add", nil, nil); errors.As(err, &rpcErr) {
+		t.Fatalf("call with a different id = %v, want an id mismatch", err)
+	}
+}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+const (
+	VerbosityNone = iota
+	VerbosityStatus
+	VerbosityHeaders
+	VerbosityBodies
+)
+
+// maxLoggedBodyBytes truncates bodies logged at VerbosityBodies.
+const maxLoggedBodyBytes = 1024
+
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+func (r *Request) logRequest(ctx context.Context) {
+	if r.c.Verbosity < VerbosityStatus {
+		return
+	}
+	if r.idempotencyKey != "" {
+		logs.CtxInfo(ctx, "rest request: %s %s idempotency key %s", r.verb, r.URL(), r.idempotencyKey)
+	} else {
+		logs.CtxInfo(ctx, "rest request: %s %s", r.verb, r.URL())
+	}
+	if r.c.Verbosity >= VerbosityHeaders && len(r.headers) > 0 {
+		logs.CtxInfo(ctx, "rest request headers: %s", formatHeaders(r.headers))
+	}
+	if r.c.Verbosity >= VerbosityBodies {
+		switch {
+		case r.body != nil:
+			logs.CtxInfo(ctx, "rest request body: <streamed>")
+		case len(r.bodyBytes) > 0:
+			logs.CtxInfo(ctx, "rest request body: %s", truncateBody(r.bodyBytes))
+		}
+	}
+}
+
+func (r *Request) logResponse(ctx context.Context, result Result, latency time.Duration) {
+	if r.c.Verbosity < VerbosityStatus {
+		return
+	}
+	if result.err != nil && result.statusCode == 0 {
+		logs.CtxWarn(ctx, "rest response: %s %s failed in %v: %v", r.verb, r.URL(), latency, result.err)
+		return
+	}
+	logs.CtxInfo(ctx, "rest response: %s %s %d in %v", r.verb, r.URL(), result.statusCode, latency)
+	if r.c.Verbosity >= VerbosityHeaders && len(result.header) > 0 {
+		logs.CtxInfo(ctx, "rest response headers: %s", formatHeaders(result.header))
+	}
+	if r.c.Verbosity >= VerbosityBodies && len(result.body) > 0 {
+		logs.CtxInfo(ctx, "rest response body: %s", truncateBody(result.body))
+	}
+}
+
+func formatHeaders(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := strings.Join(header[key], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(key)] {
+			value = "<redacted>"
+		}
+		parts = append(parts, key+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func truncateBody(body []byte) string {
+	if len(body) <= maxLoggedBodyBytes {
+		return string(body)
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", body[:maxLoggedBodyBytes], len(body)-maxLoggedBodyBytes)
+}
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/f0resee/stdlib/logs"
+	"github.com/f0resee/stdlib/logs/logtest"
+)
+
+func Test_FormatHeadersRedacts(t *testing.T) {
+	header := map[string][]string{
+		"Authorization": {"Bearer token"},
+		"Cookie":        {"session=1"},
+		"Accept":        {"application/json"},
+	}
+	got := formatHeaders(header)
+	want := "Accept: application/json; Authorization: <redacted>; Cookie: <redacted>"
+	if got != want {
+		t.Fatalf("formatHeaders = %q, want %q", got, want)
+	}
+}
+
+func Test_Verbosity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=1")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	rec := &logtest.Recorder{}
+	id := logs.Default().AddSink(rec.Sink())
+	defer logs.Default().RemoveSink(id)
+
+	cases := []struct {
+		verbosity int
+		want      []string
+	}{
+		{VerbosityNone, nil},
+		{VerbosityStatus, []string{
+			"rest request: POST " + server.URL + "/items",
+			"rest response: POST " + server.URL + "/items 200 in ",
+		}},
+		{VerbosityHeaders, []string{
+			"rest request: POST " + server.URL + "/items",
+			"rest request headers: Authorization: <redacted>; Traceparent: 00-",
+			"rest response: POST " + server.URL + "/items 200 in ",
+			"rest response headers: Content-Length: 11; Content-Type: text/plain; charset=utf-8; Date: ",
+		}},
+		{VerbosityBodies, []string{
+			"rest request: POST " + server.URL + "/items",
+			"rest request headers: Authorization: <redacted>; Traceparent: 00-",
+			`rest request body: {"name":"a"}`,
+			"rest response: POST " + server.URL + "/items 200 in ",
+			"rest response headers: Content-Length: 11; Content-Type: text/plain; charset=utf-8; Date: ",
+			`rest response body: {"ok":true}`,
+		}},
+	}
+	for _, tc := range cases {
+		restClient.Verbosity = tc.verbosity
+		rec.TakeAll()
+		result := restClient.Post().AbsPath().Suffix("/items").
+			SetHeader("Authorization", "Bearer token").
+			SetHeader(DefaultRequestIDHeader, "req-1").
+			Body([]byte(`{"name":"a"}`)).
+			Do(context.Background())
+		if result.err != nil {
+			t.Fatalf("do error: %s", result.err.Error())
+		}
+		entries := rec.FilterMessageContains("rest ").TakeAll()
+		if len(entries) != len(tc.want) {
+			t.Fatalf("verbosity %d: got %d entries, want %d: %+v", tc.verbosity, len(entries), len(tc.want), entries)
+		}
+		for i, e := range entries {
+			if !strings.HasPrefix(e.Message, tc.want[i]) {
+				t.Fatalf("verbosity %d: entry %d = %q, want prefix %q", tc.verbosity, i, e.Message, tc.want[i])
+			}
+			if e.RequestID != "req-1" {
+				t.Fatalf("verbosity %d: entry %d request id = %q", tc.verbosity, i, e.RequestID)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestMetrics describes one finished Request.Do call. URLTemplate is used
+// instead of the final URL to keep label cardinality bounded.
+type RequestMetrics struct {
+	Verb        string
+	URLTemplate string
+	StatusCode  int
+	Latency     time.Duration
+	Retries     int
+	ErrorClass  ErrorClass
+}
+
+type Metrics interface {
+	ObserveRequest(ctx context.Context, metrics RequestMetrics)
+}
+
+func (r *Request) observeMetrics(ctx context.Context, result Result, latency time.Duration) {
+	if r.c.Metrics == nil {
+		return
+	}
+	r.c.Metrics.ObserveRequest(ctx, RequestMetrics{
+		Verb:        r.verb,
+		URLTemplate: r.URLTemplate(),
+		StatusCode:  result.statusCode,
+		Latency:     latency,
+		Retries:     r.retries,
+		ErrorClass:  ClassifyError(result.err),
+	})
+}
+
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	verb        string
+	urlTemplate string
+}
+
+type requestCountKey struct {
+	requestKey
+	code       string
+	errorClass ErrorClass
+}
+
+type latencyHistogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// InMemoryMetrics aggregates RequestMetrics into counters and latency
+// histograms and can expose them in the Prometheus text format.
+type InMemoryMetrics struct {
+	mu sync.Mutex
+
+	buckets   []float64
+	requests  map[requestCountKey]uint64
+	retries   map[requestKey]uint64
+	latencies map[requestKey]*latencyHistogram
+}
+
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return NewInMemoryMetricsWithBuckets(defaultLatencyBuckets)
+}
+
+// NewInMemoryMetricsWithBuckets uses the given upper bounds, in seconds, for
+// the latency histogram.
+func NewInMemoryMetricsWithBuckets(buckets []float64) *InMemoryMetrics {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &InMemoryMetrics{
+		buckets:   sorted,
+		requests:  make(map[requestCountKey]uint64),
+		retries:   make(map[requestKey]uint64),
+		latencies: make(map[requestKey]*latencyHistogram),
+	}
+}
+
+func (m *InMemoryMetrics) ObserveRequest(_ context.Context, metrics RequestMetrics) {
+	key := requestKey{verb: metrics.Verb, urlTemplate: metrics.URLTemplate}
+	code := "<error>"
+	if metrics.StatusCode != 0 {
+		code = strconv.Itoa(metrics.StatusCode)
+	}
+	seconds := metrics.Latency.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestCountKey{requestKey: key, code: code, errorClass: metrics.ErrorClass}]++
+	m.retries[key] += uint64(metrics.Retries)
+
+	histogram, ok := m.latencies[key]
+	if !ok {
+		histogram = &latencyHistogram{buckets: make([]uint64, len(m.buckets))}
+		m.latencies[key] = histogram
+	}
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.count++
+	histogram.sum += seconds
+}
+
+// RequestCount returns how many requests were observed for verb and
+// urlTemplate, across all status codes.
+func (m *InMemoryMetrics) RequestCount(verb, urlTemplate string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if histogram, ok := m.latencies[requestKey{verb: verb, urlTemplate: urlTemplate}]; ok {
+		return histogram.count
+	}
+	return 0
+}
+
+// RetryCount returns the total retries observed for verb and urlTemplate.
+func (m *InMemoryMetrics) RetryCount(verb, urlTemplate string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.retries[requestKey{verb: verb, urlTemplate: urlTemplate}]
+}
+
+// WritePrometheus writes all series in the Prometheus text exposition format.
+func (m *InMemoryMetrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP rest_client_requests_total Number of HTTP requests, partitioned by verb, URL template, status code and error class.")
+	fmt.Fprintln(bw, "# TYPE rest_client_requests_total counter")
+	countKeys := make([]requestCountKey, 0, len(m.requests))
+	for key := range m.requests {
+		countKeys = append(countKeys, key)
+	}
+	sort.Slice(countKeys, func(i, j int) bool {
+		a, b := countKeys[i], countKeys[j]
+		if a.requestKey != b.requestKey {
+			return lessRequestKey(a.requestKey, b.requestKey)
+		}
+		if a.code != b.code {
+			return a.code < b.code
+		}
+		return a.errorClass < b.errorClass
+	})
+	for _, key := range countKeys {
+		fmt.Fprintf(bw, "rest_client_requests_total{%s,code=%q,error=%q} %d\n",
+			formatRequestLabels(key.requestKey), key.code, string(key.errorClass), m.requests[key])
+	}
+
+	keys := make([]requestKey, 0, len(m.latencies))
+	for key := range m.latencies {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return lessRequestKey(keys[i], keys[j]) })
+
+	fmt.Fprintln(bw, "# HELP rest_client_request_retries_total Number of retried attempts, partitioned by verb and URL template.")
+	fmt.Fprintln(bw, "# TYPE rest_client_request_retries_total counter")
+	for _, key := range keys {
+		fmt.Fprintf(bw, "rest_client_request_retries_total{%s} %d\n", formatRequestLabels(key), m.retries[key])
+	}
+
+	fmt.Fprintln(bw, "# HELP rest_client_request_duration_seconds Request latency including retries, partitioned by verb and URL template.")
+	fmt.Fprintln(bw, "# TYPE rest_client_request_duration_seconds histogram")
+	for _, key := range keys {
+		labels := formatRequestLabels(key)
+		histogram := m.latencies[key]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(bw, "rest_client_request_duration_seconds_bucket{%s,le=%q} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(bw, "rest_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, histogram.count)
+		fmt.Fprintf(bw, "rest_client_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(histogram.sum, 'g', -1, 64))
+		fmt.Fprintf(bw, "rest_client_request_duration_seconds_count{%s} %d\n", labels, histogram.count)
+	}
+
+	return bw.Flush()
+}
+
+// Handler serves the metrics in the Prometheus text exposition format.
+func (m *InMemoryMetrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WritePrometheus(w)
+	})
+}
+
+func lessRequestKey(a, b requestKey) bool {
+	if a.urlTemplate != b.urlTemplate {
+		return a.urlTemplate < b.urlTemplate
+	}
+	return a.verb < b.verb
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatRequestLabels(key requestKey) string {
+	return fmt.Sprintf(`verb="%s",url="%s"`, labelValueReplacer.Replace(key.verb), labelValueReplacer.Replace(key.urlTemplate))
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_InMemoryMetrics(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/users/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	metrics := NewInMemoryMetrics()
+	restClient.Metrics = metrics
+
+	result := restClient.Get().AbsPath("users", "{id}").PathParam("id", "42").
+		MaxRetries(2).RetryInterval(time.Millisecond).Do(context.Background())
+	if result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	result = restClient.Get().AbsPath("users", "{id}").PathParam("id", "missing").Do(context.Background())
+	if ClassifyError(result.err) != ErrorClassClientError {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+
+	template := server.URL + "/users/{id}"
+	if got := metrics.RequestCount(http.MethodGet, template); got != 2 {
+		t.Fatalf("request count = %d, want 2", got)
+	}
+	if got := metrics.RetryCount(http.MethodGet, template); got != 1 {
+		t.Fatalf("retry count = %d, want 1", got)
+	}
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := recorder.Body.String()
+	for _, want := range []string{
+		`rest_client_requests_total{verb="GET",url="` + template + `",code="200",error=""} 1`,
+		`rest_client_requests_total{verb="GET",url="` + template + `",code="404",error="client_error"} 1`,
+		`rest_client_request_duration_seconds_count{verb="GET",url="` + template + `"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("exposition missing %q:\n%s", want, body)
+		}
+	}
+}
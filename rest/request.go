@@ -1,7 +1,9 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"maps"
@@ -25,8 +27,9 @@ func NewRequest(c *RESTClient) *Request {
 		timeout = c.Client.Timeout
 	}
 	r := &Request{
-		c:       c,
-		timeout: timeout,
+		c:             c,
+		timeout:       timeout,
+		retryInterval: defaultRetryInterval,
 	}
 	return r
 }
@@ -45,6 +48,19 @@ type Request struct {
 	body      io.Reader
 	bodyBytes []byte
 
+	pathParams map[string]string
+
+	maxRetries     int
+	retryInterval  time.Duration
+	retries        int
+	idempotencyKey string
+
+	collectTimings bool
+	timings        *Timings
+
+	// sent is the last attempt handed to the http.Client, for CurlCommand.
+	sent *http.Request
+
 	err error
 }
 
@@ -106,6 +122,19 @@ func (r *Request) Param(paramName, s string) *Request {
 	return r.setParam(paramName, s)
 }
 
+// PathParam fills the "{name}" placeholder of the request path. The
+// unfilled path is what metrics report as the URL template.
+func (r *Request) PathParam(name, value string) *Request {
+	if r.err != nil {
+		return r
+	}
+	if r.pathParams == nil {
+		r.pathParams = make(map[string]string)
+	}
+	r.pathParams[name] = value
+	return r
+}
+
 func (r *Request) setParam(paramName, value string) *Request {
 	if r.params == nil {
 		r.params = make(url.Values)
@@ -133,14 +162,30 @@ func (r *Request) Timeout(d time.Duration) *Request {
 	return r
 }
 
+// CollectTimings records the httptrace phase breakdown of the request and
+// exposes it through Result.Timings.
+func (r *Request) CollectTimings() *Request {
+	r.collectTimings = true
+	return r
+}
+
 func (r *Request) URL() *url.URL {
-	p := r.pathPrefix
+	p := r.path()
 
 	finalURL := &url.URL{}
 	if r.c.base != nil {
 		*finalURL = *r.c.base
 	}
 	finalURL.Path = p
+	if len(r.pathParams) > 0 {
+		raw, escaped := p, p
+		for name, value := range r.pathParams {
+			raw = strings.ReplaceAll(raw, "{"+name+"}", value)
+			escaped = strings.ReplaceAll(escaped, "{"+name+"}", url.PathEscape(value))
+		}
+		finalURL.Path = raw
+		finalURL.RawPath = escaped
+	}
 
 	query := url.Values{}
 	for key, values := range r.params {
@@ -153,6 +198,22 @@ func (r *Request) URL() *url.URL {
 	return finalURL
 }
 
+// URLTemplate returns the request URL without query and with path
+// placeholders left unfilled.
+func (r *Request) URLTemplate() string {
+	if r.c.base == nil {
+		return r.path()
+	}
+	return r.c.base.Scheme + "://" + r.c.base.Host + r.path()
+}
+
+func (r *Request) path() string {
+	if r.subpath == "" {
+		return r.pathPrefix
+	}
+	return path.Join(r.pathPrefix, r.subpath)
+}
+
 func (r *Request) Body(obj interface{}) *Request {
 	if r.err != nil {
 		return r
@@ -183,16 +244,39 @@ func (r *Request) Error() error {
 }
 
 func (r *Request) newHTTPRequest(ctx context.Context) (*http.Request, error) {
+	req, err := r.buildHTTPRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.sign(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// buildHTTPRequest builds an unsigned request from r without changing r.
+func (r *Request) buildHTTPRequest(ctx context.Context) (*http.Request, error) {
 	url := r.URL().String()
-	req, err := http.NewRequestWithContext(ctx, r.verb, url, r.body)
+	body := r.body
+	if r.bodyBytes != nil {
+		body = bytes.NewReader(r.bodyBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.verb, url, body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = r.headers
+	req.Header = r.headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
 	return req, nil
 }
 
 func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Response)) error {
+	if r.err != nil {
+		return r.err
+	}
+
 	client := r.c.Client
 	if client == nil {
 		client = http.DefaultClient
@@ -204,31 +288,112 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 		defer cancel()
 	}
 
-	req, err := r.newHTTPRequest(ctx)
-	if err != nil {
-		return err
-	}
+	r.retries = 0
+	for {
+		attemptCtx := ctx
+		var tracer *timingsTracer
+		if r.collectTimings || r.c.TimingsObserver != nil {
+			tracer = newTimingsTracer()
+			attemptCtx = tracer.withContext(ctx)
+		}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
+		req, err := r.newHTTPRequest(attemptCtx)
+		if err != nil {
+			return err
+		}
+		r.sent = req
+
+		resp, err := client.Do(req)
+		if err != nil {
+			r.observeTimings(req, tracer)
+			if r.shouldRetry(ctx, nil, err) {
+				if err := r.waitRetry(ctx, r.retryInterval); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
 
-	f := func(req *http.Request, resp *http.Response) {
-		if resp == nil {
-			return
+		if r.shouldRetry(ctx, resp, nil) {
+			delay := r.retryDelay(resp)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+			resp.Body.Close()
+			r.observeTimings(req, tracer)
+			if err := r.waitRetry(ctx, delay); err != nil {
+				return err
+			}
+			continue
 		}
-		fn(req, resp)
+
+		f := func(req *http.Request, resp *http.Response) {
+			defer resp.Body.Close()
+			fn(req, resp)
+		}
+		f(req, resp)
+		r.observeTimings(req, tracer)
+		return nil
+	}
+}
+
+func (r *Request) observeTimings(req *http.Request, tracer *timingsTracer) {
+	if tracer == nil {
+		return
+	}
+	timings := tracer.finish()
+	r.timings = &timings
+	if r.c.TimingsObserver != nil {
+		r.c.TimingsObserver.ObserveTimings(req, timings)
 	}
-	f(req, resp)
-	return nil
 }
 
 type Result struct {
 	body        []byte
 	contentType string
+	header      http.Header
+	finalURL    *url.URL
 	err         error
 	statusCode  int
+	timings     *Timings
+}
+
+// Raw returns the response body and the request error.
+func (r Result) Raw() ([]byte, error) {
+	return r.body, r.err
+}
+
+func (r Result) Error() error {
+	return r.err
+}
+
+// StatusCode returns the HTTP status, or 0 when no response was received.
+func (r Result) StatusCode() int {
+	return r.statusCode
+}
+
+func (r Result) ContentType() string {
+	return r.contentType
+}
+
+func (r Result) Header() http.Header {
+	return r.header
+}
+
+// Into decodes the JSON response body into obj.
+func (r Result) Into(obj interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.body) == 0 {
+		return fmt.Errorf("0-length response")
+	}
+	return json.Unmarshal(r.body, obj)
+}
+
+// Timings returns the phase breakdown of the request, or nil when timings
+// were not collected.
+func (r Result) Timings() *Timings {
+	return r.timings
 }
 
 func (r *Request) transformResponse(_ context.Context, resp *http.Response, _ *http.Request) Result {
@@ -255,25 +420,43 @@ func (r *Request) transformResponse(_ context.Context, resp *http.Response, _ *h
 		return Result{
 			body:        body,
 			contentType: resp.Header.Get("Content-Type"),
+			header:      resp.Header,
+			finalURL:    resp.Request.URL,
 			statusCode:  resp.StatusCode,
-			err:         fmt.Errorf("status not ok"),
+			err:         &StatusError{StatusCode: resp.StatusCode, Body: body},
 		}
 	}
 
 	return Result{
 		body:        body,
 		contentType: resp.Header.Get("Content-Type"),
+		header:      resp.Header,
+		finalURL:    resp.Request.URL,
 		statusCode:  resp.StatusCode,
 	}
 }
 
 func (r *Request) Do(ctx context.Context) Result {
+	if err := r.c.acquire(); err != nil {
+		return Result{err: err}
+	}
+	defer r.c.release()
+
+	start := time.Now()
+	ctx = r.withRequestID(ctx)
+	ctx = r.withTraceContext(ctx)
+	r.logRequest(ctx)
+
 	var result Result
 	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
 		result = r.transformResponse(ctx, resp, req)
 	})
 	if err != nil {
-		return Result{err: err}
+		result = Result{err: err}
 	}
+	result.timings = r.timings
+	latency := time.Since(start)
+	r.logResponse(ctx, result, latency)
+	r.observeMetrics(ctx, result, latency)
 	return result
 }
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func Test_AbsPath(t *testing.T) {
+	cases := []struct {
+		base     string
+		build    func(r *Request) *Request
+		wantPath string
+	}{
+		{"http://127.0.0.1:8000", func(r *Request) *Request { return r.AbsPath("/items") }, "/items/"},
+		{"http://127.0.0.1:8000", func(r *Request) *Request { return r.AbsPath("items") }, "/items"},
+		{"http://127.0.0.1:8000", func(r *Request) *Request { return r.AbsPath("/users", "42") }, "/users/42"},
+		{"http://127.0.0.1:8000/api", func(r *Request) *Request { return r.AbsPath("items") }, "/api/items/"},
+		{"http://127.0.0.1:8000/api", func(r *Request) *Request { return r.AbsPath("/items/") }, "/api/items/"},
+		{"http://127.0.0.1:8000/api", func(r *Request) *Request { return r.AbsPath() }, "/api"},
+		{"http://127.0.0.1:8000/api", func(r *Request) *Request { return r.AbsPath().Suffix("/rpc") }, "/api/rpc"},
+		{"http://127.0.0.1:8000", func(r *Request) *Request { return r.AbsPath().Suffix("/rpc") }, "/rpc"},
+	}
+	for _, tc := range cases {
+		base, _ := url.Parse(tc.base)
+		restClient, err := NewRESTClient(base, http.DefaultClient)
+		if err != nil {
+			t.Fatalf("new rest client error: %s", err.Error())
+		}
+		if got := tc.build(restClient.Get()).URL().Path; got != tc.wantPath {
+			t.Fatalf("base %s: path = %q, want %q", tc.base, got, tc.wantPath)
+		}
+	}
+}
+
+func Test_ResultAccessors(t *testing.T) {
+	header := http.Header{"Content-Type": {"application/json"}}
+	result := Result{body: []byte(`{"name":"a"}`), contentType: "application/json", statusCode: http.StatusOK, header: header}
+
+	if body, err := result.Raw(); err != nil || string(body) != `{"name":"a"}` {
+		t.Fatalf("Raw() = %q, %v", body, err)
+	}
+	if result.Error() != nil || result.StatusCode() != http.StatusOK || result.ContentType() != "application/json" {
+		t.Fatalf("unexpected accessors: %v %d %q", result.Error(), result.StatusCode(), result.ContentType())
+	}
+	if result.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected header %v", result.Header())
+	}
+	var out struct{ Name string }
+	if err := result.Into(&out); err != nil || out.Name != "a" {
+		t.Fatalf("Into() = %+v, %v", out, err)
+	}
+
+	if err := (Result{statusCode: http.StatusNoContent}).Into(&out); err == nil {
+		t.Fatalf("Into() of an empty body succeeded")
+	}
+	failed := Result{err: &StatusError{StatusCode: http.StatusNotFound}, body: []byte("{}"), statusCode: http.StatusNotFound}
+	if err := failed.Into(&out); err != failed.err {
+		t.Fatalf("Into() = %v, want the request error", err)
+	}
+	if _, err := failed.Raw(); err != failed.err {
+		t.Fatalf("Raw() error = %v, want the request error", err)
+	}
+	if (Result{}).StatusCode() != 0 {
+		t.Fatalf("StatusCode() without a response is not 0")
+	}
+}
+
+func Test_SuccessStatuses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	for code, success := range map[int]bool{
+		http.StatusOK:                  true,
+		http.StatusCreated:             false,
+		http.StatusAccepted:            false,
+		http.StatusNoContent:           false,
+		http.StatusNotModified:         false,
+		http.StatusBadRequest:          false,
+		http.StatusInternalServerError: false,
+	} {
+		result := restClient.Get().AbsPath().Suffix("status").Param("code", strconv.Itoa(code)).Do(context.Background())
+		if result.StatusCode() != code {
+			t.Fatalf("status %d: StatusCode() = %d", code, result.StatusCode())
+		}
+		var statusErr *StatusError
+		if success && result.Error() != nil {
+			t.Fatalf("status %d: unexpected error %v", code, result.Error())
+		}
+		if !success && (!errors.As(result.Error(), &statusErr) || statusErr.StatusCode != code) {
+			t.Fatalf("status %d: error = %v, want *StatusError", code, result.Error())
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/f0resee/stdlib/logs"
+)
+
+const (
+	DefaultRequestIDHeader = "X-Request-ID"
+
+	traceParentHeader = "traceparent"
+	traceStateHeader  = "tracestate"
+)
+
+// NewRequestID returns a random 128-bit identifier in hex.
+func NewRequestID() string {
+	return randomHex(16)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (c *RESTClient) requestIDHeader() string {
+	if c.RequestIDHeader != "" {
+		return c.RequestIDHeader
+	}
+	return DefaultRequestIDHeader
+}
+
+// withRequestID makes sure the request carries a request ID header and
+// returns a context keyed by logs.RequestID{} holding the same value. An ID
+// already set on the request wins over the one in ctx; if neither exists a
+// new one is generated.
+func (r *Request) withRequestID(ctx context.Context) context.Context {
+	header := r.c.requestIDHeader()
+	id := r.headers.Get(header)
+	if id == "" {
+		id, _ = ctx.Value(logs.RequestID{}).(string)
+	}
+	if id == "" {
+		id = NewRequestID()
+	}
+	r.SetHeader(header, id)
+	if current, _ := ctx.Value(logs.RequestID{}).(string); current == id {
+		return ctx
+	}
+	return context.WithValue(ctx, logs.RequestID{}, id)
+}
+
+// withTraceContext propagates the W3C trace context found in ctx and
+// returns a context holding the traceparent sent, so log lines show it. The
+// trace ID and flags are kept and a new parent ID is generated for the
+// outgoing call. A missing or malformed traceparent starts a new trace, and
+// a tracestate without a valid traceparent is dropped.
+func (r *Request) withTraceContext(ctx context.Context) context.Context {
+	traceParent, _ := ctx.Value(logs.TraceParent{}).(string)
+	child, ok := childTraceParent(traceParent)
+	if !ok {
+		child = strings.Join([]string{"00", randomHex(16), randomHex(8), "00"}, "-")
+		r.SetHeader(traceParentHeader, child)
+		r.headers.Del(traceStateHeader)
+		ctx = context.WithValue(ctx, logs.TraceState{}, "")
+		return context.WithValue(ctx, logs.TraceParent{}, child)
+	}
+	r.SetHeader(traceParentHeader, child)
+	if traceState, _ := ctx.Value(logs.TraceState{}).(string); traceState != "" {
+		r.SetHeader(traceStateHeader, traceState)
+	}
+	return context.WithValue(ctx, logs.TraceParent{}, child)
+}
+
+// childTraceParent replaces the parent ID of a valid traceparent.
+func childTraceParent(traceParent string) (string, bool) {
+	tc, ok := logs.ParseTraceParent(traceParent)
+	if !ok {
+		return "", false
+	}
+	return strings.Join([]string{"00", tc.TraceID, randomHex(8), tc.Flags}, "-"), true
+}
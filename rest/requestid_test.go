@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/f0resee/stdlib/logs"
+	"github.com/f0resee/stdlib/logs/logtest"
+)
+
+func Test_RequestIDPropagation(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	ctx := context.WithValue(context.Background(), logs.RequestID{}, "req-1")
+	ctx = context.WithValue(ctx, logs.TraceParent{}, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx = context.WithValue(ctx, logs.TraceState{}, "congo=t61rcWkgMzE")
+	if result := restClient.Get().AbsPath("/id").Do(ctx); result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	if id := got.Get(DefaultRequestIDHeader); id != "req-1" {
+		t.Fatalf("request id = %q, want req-1", id)
+	}
+	traceParent := got.Get("traceparent")
+	if !strings.HasPrefix(traceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(traceParent, "00f067aa0ba902b7") {
+		t.Fatalf("unexpected traceparent %q", traceParent)
+	}
+	if traceState := got.Get("tracestate"); traceState != "congo=t61rcWkgMzE" {
+		t.Fatalf("unexpected tracestate %q", traceState)
+	}
+
+	restClient.RequestIDHeader = "X-Correlation-ID"
+	if result := restClient.Get().AbsPath("/id").Do(context.Background()); result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	if id := got.Get("X-Correlation-ID"); len(id) != 32 {
+		t.Fatalf("generated request id = %q", id)
+	}
+	if _, ok := childTraceParent(got.Get("traceparent")); !ok {
+		t.Fatalf("no new trace started without trace context: %q", got.Get("traceparent"))
+	}
+}
+
+func Test_TraceContextLogged(t *testing.T) {
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	restClient.Verbosity = VerbosityStatus
+	rec := &logtest.Recorder{}
+	id := logs.Default().AddSink(rec.Sink())
+	defer logs.Default().RemoveSink(id)
+
+	for _, traceParent := range []string{"", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "malformed"} {
+		rec.TakeAll()
+		ctx := context.Background()
+		if traceParent != "" {
+			ctx = context.WithValue(ctx, logs.TraceParent{}, traceParent)
+		}
+		if result := restClient.Get().AbsPath("/id").Do(ctx); result.err != nil {
+			t.Fatalf("do error: %s", result.err.Error())
+		}
+		tc, ok := logs.ParseTraceParent(sent)
+		if !ok {
+			t.Fatalf("inbound %q: sent traceparent %q", traceParent, sent)
+		}
+		entries := rec.FilterMessageContains("rest ").All()
+		if len(entries) != 2 {
+			t.Fatalf("inbound %q: got %d entries", traceParent, len(entries))
+		}
+		for _, e := range entries {
+			if e.TraceID != tc.TraceID || e.SpanID != tc.SpanID {
+				t.Fatalf("inbound %q: logged trace %s/%s, sent %q", traceParent, e.TraceID, e.SpanID, sent)
+			}
+		}
+	}
+}
+
+func Test_childTraceParent(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, traceParent := range []string{
+		"00-" + traceID + "-00f067aa0ba902b7-01",
+		"01-" + traceID + "-00f067aa0ba902b7-01-extra",
+	} {
+		child, ok := childTraceParent(traceParent)
+		if !ok || !strings.HasPrefix(child, "00-"+traceID+"-") || !strings.HasSuffix(child, "-01") {
+			t.Fatalf("childTraceParent(%q) = %q, %v", traceParent, child, ok)
+		}
+	}
+	for _, traceParent := range []string{
+		"00-" + traceID + "-00f067aa0ba902b7-01-extra",
+		"ff-" + traceID + "-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-" + traceID + "-0000000000000000-01",
+		"00-" + strings.ToUpper(traceID) + "-00f067aa0ba902b7-01",
+		"00-" + traceID + "-00f067aa0ba902b7",
+	} {
+		if child, ok := childTraceParent(traceParent); ok {
+			t.Fatalf("childTraceParent(%q) = %q, want rejected", traceParent, child)
+		}
+	}
+}
+
+func Test_InvalidTraceParentStartsNewTrace(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	const invalid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"
+	ctx := context.WithValue(context.Background(), logs.TraceParent{}, invalid)
+	ctx = context.WithValue(ctx, logs.TraceState{}, "congo=t61rcWkgMzE")
+	if result := restClient.Get().AbsPath("/id").Do(ctx); result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	traceParent := got.Get("traceparent")
+	if _, ok := childTraceParent(traceParent); !ok || strings.Contains(traceParent, "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("unexpected traceparent %q", traceParent)
+	}
+	if traceState := got.Get("tracestate"); traceState != "" {
+		t.Fatalf("tracestate %q kept for a new trace", traceState)
+	}
+}
@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryInterval = time.Second
+
+	// maxRetryDelay caps the wait a server can ask for with Retry-After.
+	maxRetryDelay = time.Minute
+
+	// maxDrainBytes bounds how much of a discarded response is read so the
+	// connection can be reused for the next attempt.
+	maxDrainBytes = 4 << 10
+)
+
+// MaxRetries sets how many times an idempotent request is retried after a
+// connection error or a 429/502/503/504 response. POST and PATCH are only
+// retried once marked Idempotent. Zero disables retries.
+func (r *Request) MaxRetries(maxRetries int) *Request {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	r.maxRetries = maxRetries
+	return r
+}
+
+// RetryInterval sets the wait between attempts when the server does not send
+// a Retry-After header.
+func (r *Request) RetryInterval(d time.Duration) *Request {
+	r.retryInterval = d
+	return r
+}
+
+func (r *Request) retryable() bool {
+	// A streamed body can only be sent once.
+	if r.body != nil {
+		return false
+	}
+	switch r.verb {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	case http.MethodPost, http.MethodPatch:
+		return r.idempotencyKey != ""
+	}
+	return false
+}
+
+func (r *Request) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if r.retries >= r.maxRetries || ctx.Err() != nil || !r.retryable() {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay honors Retry-After as delay-seconds or as an HTTP-date, capped
+// at maxRetryDelay.
+func (r *Request) retryDelay(resp *http.Response) time.Duration {
+	retryAfter := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		if seconds > int(maxRetryDelay/time.Second) {
+			return maxRetryDelay
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(retryAfter); err == nil {
+		return min(max(time.Until(at), 0), maxRetryDelay)
+	}
+	return r.retryInterval
+}
+
+func (r *Request) waitRetry(ctx context.Context, delay time.Duration) error {
+	r.retries++
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
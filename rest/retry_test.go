@@ -0,0 +1,206 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newRetryTestClient(t *testing.T, handler http.HandlerFunc) *RESTClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	return restClient
+}
+
+func Test_RetryStatusCodes(t *testing.T) {
+	for _, code := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+		var calls int32
+		restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(code)
+				return
+			}
+			w.Write([]byte("ok"))
+		})
+		result := restClient.Get().AbsPath("/items").MaxRetries(1).RetryInterval(time.Millisecond).Do(context.Background())
+		if result.err != nil {
+			t.Fatalf("status %d: do error: %s", code, result.err.Error())
+		}
+		if calls != 2 {
+			t.Fatalf("status %d: calls = %d, want 2", code, calls)
+		}
+	}
+}
+
+func Test_RetryNotRetried(t *testing.T) {
+	cases := []struct {
+		name  string
+		build func(c *RESTClient) *Request
+	}{
+		{"internal server error", func(c *RESTClient) *Request { return c.Get().AbsPath("/items") }},
+		{"post", func(c *RESTClient) *Request { return c.Post().AbsPath("/items").Body([]byte("{}")) }},
+		{"patch", func(c *RESTClient) *Request { return c.Verb(http.MethodPatch).AbsPath("/items").Body([]byte("{}")) }},
+		{"streamed body", func(c *RESTClient) *Request { return c.Put().AbsPath("/items").Body(strings.NewReader("{}")) }},
+		{"zero retries", func(c *RESTClient) *Request { return c.Get().AbsPath("/items").MaxRetries(0) }},
+	}
+	for _, tc := range cases {
+		var calls int32
+		restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if tc.name == "internal server error" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		req := tc.build(restClient)
+		if tc.name != "zero retries" {
+			req.MaxRetries(3)
+		}
+		req.RetryInterval(time.Millisecond).Do(context.Background())
+		if calls != 1 {
+			t.Fatalf("%s: calls = %d, want 1", tc.name, calls)
+		}
+	}
+}
+
+func Test_RetryMaxRetries(t *testing.T) {
+	var calls int32
+	restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	result := restClient.Get().AbsPath("/items").MaxRetries(2).RetryInterval(time.Millisecond).Do(context.Background())
+	var statusErr *StatusError
+	if !errors.As(result.err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func Test_RetryDelay(t *testing.T) {
+	r := &Request{retryInterval: 5 * time.Millisecond}
+	cases := []struct {
+		retryAfter string
+		want       time.Duration
+	}{
+		{"", 5 * time.Millisecond},
+		{"bad", 5 * time.Millisecond},
+		{"-1", 5 * time.Millisecond},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{"86400", maxRetryDelay},
+	}
+	for _, tc := range cases {
+		resp := &http.Response{Header: http.Header{}}
+		if tc.retryAfter != "" {
+			resp.Header.Set("Retry-After", tc.retryAfter)
+		}
+		if got := r.retryDelay(resp); got != tc.want {
+			t.Fatalf("Retry-After %q: delay = %v, want %v", tc.retryAfter, got, tc.want)
+		}
+	}
+
+	dates := []struct {
+		at       time.Time
+		min, max time.Duration
+	}{
+		{time.Now().Add(-time.Hour), 0, 0},
+		{time.Now().Add(10 * time.Second), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(24 * time.Hour), maxRetryDelay, maxRetryDelay},
+	}
+	for _, tc := range dates {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", tc.at.UTC().Format(http.TimeFormat))
+		if got := r.retryDelay(resp); got < tc.min || got > tc.max {
+			t.Fatalf("Retry-After %q: delay = %v, want between %v and %v", resp.Header.Get("Retry-After"), got, tc.min, tc.max)
+		}
+	}
+}
+
+func Test_RetryContextCanceled(t *testing.T) {
+	restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result := restClient.Get().AbsPath("/items").MaxRetries(1).Do(ctx)
+	if !errors.Is(result.err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("retry wait ignored the context: %v", elapsed)
+	}
+}
+
+func Test_PathParamAndURLTemplate(t *testing.T) {
+	base, _ := url.Parse("http://127.0.0.1:8000/api")
+	restClient, err := NewRESTClient(base, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	req := restClient.Get().AbsPath("/users/{id}").Suffix("files", "{name}").
+		PathParam("id", "42").PathParam("name", "a b/c")
+	if got, want := req.URLTemplate(), "http://127.0.0.1:8000/api/users/{id}/files/{name}"; got != want {
+		t.Fatalf("URLTemplate() = %q, want %q", got, want)
+	}
+	if got, want := req.URL().String(), "http://127.0.0.1:8000/api/users/42/files/a%20b%2Fc"; got != want {
+		t.Fatalf("URL() = %q, want %q", got, want)
+	}
+}
+
+func Test_StatusError(t *testing.T) {
+	restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+	result := restClient.Get().AbsPath("/items").Do(context.Background())
+	var statusErr *StatusError
+	if !errors.As(result.err, &statusErr) {
+		t.Fatalf("unexpected error: %v", result.err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != "missing" {
+		t.Fatalf("unexpected status error: %d %q", statusErr.StatusCode, statusErr.Body)
+	}
+	if statusErr.Error() != "status not ok: 404" {
+		t.Fatalf("unexpected message: %s", statusErr.Error())
+	}
+}
+
+func Test_RetryResendsBody(t *testing.T) {
+	var bodies []string
+	restClient := newRetryTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	result := restClient.Put().AbsPath("/items").Body([]byte("payload")).
+		MaxRetries(1).RetryInterval(time.Millisecond).Do(context.Background())
+	if result.err != nil {
+		t.Fatalf("do error: %s", result.err.Error())
+	}
+	if len(bodies) != 2 || bodies[0] != "payload" || bodies[1] != "payload" {
+		t.Fatalf("unexpected bodies: %q", bodies)
+	}
+}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Session runs requests against an upstream that uses cookie-based login.
+// When a response shows the session is gone, Login is run once and the
+// original request is replayed once. The RESTClient needs a cookie jar,
+// see Config.Jar.
+type Session struct {
+	client *RESTClient
+	login  func(ctx context.Context, client *RESTClient) error
+
+	// ReloginStatus lists the statuses that trigger a login, by default
+	// 401 and 403.
+	ReloginStatus []int
+	// LoginPath, when set, also triggers a login when a response redirects
+	// to a URL whose path starts with it.
+	LoginPath string
+
+	mu         sync.Mutex
+	generation uint64
+}
+
+func NewSession(client *RESTClient, login func(ctx context.Context, client *RESTClient) error) *Session {
+	return &Session{
+		client:        client,
+		login:         login,
+		ReloginStatus: []int{http.StatusUnauthorized, http.StatusForbidden},
+	}
+}
+
+// Login runs the login request unless another goroutine did so since
+// generation was observed.
+func (s *Session) Login(ctx context.Context) error {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+	return s.relogin(ctx, generation)
+}
+
+func (s *Session) relogin(ctx context.Context, seen uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation != seen {
+		return nil
+	}
+	if err := s.login(ctx, s.client); err != nil {
+		return err
+	}
+	s.generation++
+	return nil
+}
+
+// Do runs req and, if the session expired, logs in and replays it once.
+// Requests with a streamed body cannot be replayed.
+func (s *Session) Do(ctx context.Context, req *Request) Result {
+	s.mu.Lock()
+	generation := s.generation
+	s.mu.Unlock()
+
+	result := req.Do(ctx)
+	if !s.needsLogin(req, result) || req.body != nil {
+		return result
+	}
+	if err := s.relogin(ctx, generation); err != nil {
+		return Result{err: err}
+	}
+	return req.Do(ctx)
+}
+
+func (s *Session) needsLogin(req *Request, result Result) bool {
+	for _, status := range s.ReloginStatus {
+		if result.statusCode == status {
+			return true
+		}
+	}
+	if s.LoginPath == "" || strings.HasPrefix(req.URL().Path, s.LoginPath) {
+		return false
+	}
+	// A followed redirect ends on the login page, an unfollowed one points
+	// to it.
+	if result.finalURL != nil && strings.HasPrefix(result.finalURL.Path, s.LoginPath) {
+		return true
+	}
+	var statusErr *StatusError
+	if errors.As(result.err, &statusErr) && statusErr.StatusCode >= 300 && statusErr.StatusCode < 400 {
+		location := result.header.Get("Location")
+		if u, err := req.URL().Parse(location); err == nil {
+			return strings.HasPrefix(u.Path, s.LoginPath)
+		}
+	}
+	return false
+}
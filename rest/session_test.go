@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func sessionServer(logins *int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		*logins++
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "valid", Path: "/", MaxAge: 3600})
+	})
+	mux.HandleFunc("/login-page", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("please log in"))
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("data"))
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			http.Redirect(w, r, "/login-page", http.StatusFound)
+			return
+		}
+		w.Write([]byte("page"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func login(ctx context.Context, client *RESTClient) error {
+	return client.Post().AbsPath().Suffix("/login").Do(ctx).Error()
+}
+
+func Test_SessionRelogin(t *testing.T) {
+	var logins int
+	server := sessionServer(&logins)
+	defer server.Close()
+
+	cookieFile := filepath.Join(t.TempDir(), "cookies.json")
+	jar, err := NewFileCookieJar(cookieFile)
+	if err != nil {
+		t.Fatalf("new file cookie jar error: %s", err.Error())
+	}
+	restClient, err := RESTClientFor(&Config{Host: server.URL, Jar: jar})
+	if err != nil {
+		t.Fatalf("rest client error: %s", err.Error())
+	}
+
+	session := NewSession(restClient, login)
+	result := session.Do(context.Background(), restClient.Get().AbsPath().Suffix("/data"))
+	if result.Error() != nil || string(result.body) != "data" || logins != 1 {
+		t.Fatalf("unexpected result %q, %v after %d logins", result.body, result.Error(), logins)
+	}
+
+	// A second client reading the same file reuses the session.
+	jar, err = NewFileCookieJar(cookieFile)
+	if err != nil {
+		t.Fatalf("reload file cookie jar error: %s", err.Error())
+	}
+	restClient, _ = RESTClientFor(&Config{Host: server.URL, Jar: jar})
+	if result := restClient.Get().AbsPath().Suffix("/data").Do(context.Background()); result.Error() != nil {
+		t.Fatalf("persisted session not reused: %v", result.Error())
+	}
+}
+
+func Test_SessionLoginRedirect(t *testing.T) {
+	var logins int
+	server := sessionServer(&logins)
+	defer server.Close()
+
+	jar, _ := NewFileCookieJar(filepath.Join(t.TempDir(), "cookies.json"))
+	restClient, _ := RESTClientFor(&Config{Host: server.URL, Jar: jar})
+
+	session := NewSession(restClient, login)
+	session.LoginPath = "/login-page"
+	result := session.Do(context.Background(), restClient.Get().AbsPath().Suffix("/page"))
+	if result.Error() != nil || string(result.body) != "page" || logins != 1 {
+		t.Fatalf("unexpected result %q, %v after %d logins", result.body, result.Error(), logins)
+	}
+}
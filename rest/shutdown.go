@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrClientClosed is returned for requests started after RESTClient.Close.
+var ErrClientClosed = errors.New("rest client is closed")
+
+func (c *RESTClient) acquire() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClientClosed
+	}
+	c.inflight++
+	return nil
+}
+
+func (c *RESTClient) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inflight--
+	if c.closed && c.inflight == 0 {
+		c.signalDrained()
+	}
+}
+
+// signalDrained must be called with c.mu held.
+func (c *RESTClient) signalDrained() {
+	select {
+	case <-c.drained:
+	default:
+		close(c.drained)
+	}
+}
+
+// trackWebSocket counts conn as in flight until it is closed. The caller
+// must hold the slot taken by acquire, which conn then releases.
+func (c *RESTClient) trackWebSocket(conn *WebSocketConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.websockets == nil {
+		c.websockets = make(map[*WebSocketConn]struct{})
+	}
+	c.websockets[conn] = struct{}{}
+	conn.onClose = func() {
+		c.mu.Lock()
+		delete(c.websockets, conn)
+		c.mu.Unlock()
+		c.release()
+	}
+}
+
+// Close rejects new requests with ErrClientClosed, closes open WebSocket
+// connections with CloseGoingAway and waits for in-flight Do calls and
+// those connections to finish, or for ctx to be done, before closing idle
+// connections. It returns ctx.Err() when requests were still running.
+func (c *RESTClient) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.drained = make(chan struct{})
+		if c.inflight == 0 {
+			c.signalDrained()
+		}
+		if c.closedCh != nil {
+			close(c.closedCh)
+		}
+	}
+	drained := c.drained
+	websockets := make([]*WebSocketConn, 0, len(c.websockets))
+	for conn := range c.websockets {
+		websockets = append(websockets, conn)
+	}
+	c.mu.Unlock()
+
+	for _, conn := range websockets {
+		go conn.CloseWithStatus(CloseGoingAway, "")
+	}
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if c.Client != nil {
+		c.Client.CloseIdleConnections()
+	}
+	return err
+}
+
+// closedChan returns a channel closed once Close is called.
+func (c *RESTClient) closedChan() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closedCh == nil {
+		c.closedCh = make(chan struct{})
+		if c.closed {
+			close(c.closedCh)
+		}
+	}
+	return c.closedCh
+}
+
+// CloseOnStop closes the client once stopCh, usually from
+// signal.SetupStopCh, is closed, draining for at most timeout. The returned
+// channel receives the result of Close. When the client is closed directly
+// first, the channel is closed without a value.
+func (c *RESTClient) CloseOnStop(stopCh <-chan struct{}, timeout time.Duration) <-chan error {
+	done := make(chan error, 1)
+	closed := c.closedChan()
+	go func() {
+		select {
+		case <-stopCh:
+		case <-closed:
+			close(done)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		done <- c.Close(ctx)
+	}()
+	return done
+}
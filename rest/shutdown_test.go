@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_CloseDrainsInFlight(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.Write([]byte("done"))
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	results := make(chan Result, 1)
+	go func() {
+		results <- restClient.Get().AbsPath("/slow").Do(context.Background())
+	}()
+	<-entered
+
+	stopCh := make(chan struct{})
+	closed := restClient.CloseOnStop(stopCh, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := restClient.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("close with in-flight request = %v, want deadline exceeded", err)
+	}
+	if result := restClient.Get().AbsPath("/slow").Do(context.Background()); !errors.Is(result.Error(), ErrClientClosed) {
+		t.Fatalf("request after close = %v, want ErrClientClosed", result.Error())
+	}
+
+	close(stopCh)
+	close(release)
+	if result := <-results; result.Error() != nil || string(result.body) != "done" {
+		t.Fatalf("in-flight request failed: %v", result.Error())
+	}
+	if err := <-closed; err != nil {
+		t.Fatalf("close on stop error: %s", err.Error())
+	}
+}
+
+func Test_CloseOnStopExitsAfterClose(t *testing.T) {
+	hostURL, _ := url.Parse("http://127.0.0.1:8000")
+	restClient, err := NewRESTClient(hostURL, nil)
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+	closed := restClient.CloseOnStop(make(chan struct{}), time.Second)
+	if err := restClient.Close(context.Background()); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+	select {
+	case _, ok := <-closed:
+		if ok {
+			t.Fatalf("close on stop sent a result for a direct Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("close on stop goroutine still waits for the stop channel")
+	}
+	// Registering after Close returns at once as well.
+	if _, ok := <-restClient.CloseOnStop(make(chan struct{}), time.Second); ok {
+		t.Fatalf("close on stop after Close sent a result")
+	}
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func Test_HMACSHA256Signer(t *testing.T) {
+	secret := []byte("secret")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		bodyHash := hex.EncodeToString(sum[:])
+		if r.Header.Get(HeaderContentSHA256) != bodyHash {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(HMACSHA256StringToSign(r.Method, r.URL, bodyHash, r.Header.Get(HeaderSignatureTimestamp))))
+		want := fmt.Sprintf("HMAC-SHA256 Credential=key-1, Signature=%s", hex.EncodeToString(mac.Sum(nil)))
+		if r.Header.Get("Authorization") != want {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+	restClient.Signer = &HMACSHA256Signer{KeyID: "key-1", Secret: secret}
+
+	result := restClient.Post().AbsPath("/items").Param("b", "2").Param("a", "1").Body([]byte(`{"x":1}`)).Do(context.Background())
+	if result.Error() != nil {
+		t.Fatalf("signed request rejected: %v", result.Error())
+	}
+	result = restClient.Post().AbsPath("/items").Body(strings.NewReader("streamed")).Do(context.Background())
+	if result.Error() == nil {
+		t.Fatalf("expected signing a streamed body to fail")
+	}
+}
+
+type staticAuth struct{}
+
+func (staticAuth) HTTPHeaders(url string, appID string, secret string) map[string][]string {
+	return map[string][]string{"Authorization": {appID + ":" + secret + ":" + url}}
+}
+
+func Test_ApolloSignerAndHTTPAuthAdapter(t *testing.T) {
+	var got http.Header
+	var gotURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		gotURI = r.URL.RequestURI()
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+	restClient.Signer = &ApolloSigner{AppID: "app", Secret: "secret"}
+	if result := restClient.Get().AbsPath("/configs/app/default").Param("ip", "1.2.3.4").Do(context.Background()); result.Error() != nil {
+		t.Fatalf("do error: %v", result.Error())
+	}
+	mac := hmac.New(sha1.New, []byte("secret"))
+	mac.Write([]byte(got.Get("Timestamp") + "\n" + gotURI))
+	if want := "Apollo app:" + base64.StdEncoding.EncodeToString(mac.Sum(nil)); got.Get("Authorization") != want {
+		t.Fatalf("authorization = %q, want %q", got.Get("Authorization"), want)
+	}
+
+	restClient.Signer = &HTTPAuthSigner{Auth: staticAuth{}, AppID: "app", Secret: "secret"}
+	if result := restClient.Get().AbsPath().Suffix("/notifications").Do(context.Background()); result.Error() != nil {
+		t.Fatalf("do error: %v", result.Error())
+	}
+	if want := "app:secret:" + server.URL + "/notifications"; got.Get("Authorization") != want {
+		t.Fatalf("authorization = %q, want %q", got.Get("Authorization"), want)
+	}
+}
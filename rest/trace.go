@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the phase breakdown of a single request attempt collected
+// through net/http/httptrace. Phases that did not happen, such as DNS and
+// connect on a reused connection, are left zero.
+type Timings struct {
+	DNSLookup        time.Duration
+	Connect          time.Duration
+	TLSHandshake     time.Duration
+	ServerProcessing time.Duration
+	ContentTransfer  time.Duration
+	Total            time.Duration
+
+	ConnReused   bool
+	ConnWasIdle  bool
+	ConnIdleTime time.Duration
+	RemoteAddr   string
+}
+
+type TimingsObserver interface {
+	ObserveTimings(req *http.Request, timings Timings)
+}
+
+type TimingsObserverFunc func(req *http.Request, timings Timings)
+
+func (f TimingsObserverFunc) ObserveTimings(req *http.Request, timings Timings) {
+	f(req, timings)
+}
+
+type timingsTracer struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+
+	timings Timings
+}
+
+func newTimingsTracer() *timingsTracer {
+	return &timingsTracer{start: time.Now()}
+}
+
+func (t *timingsTracer) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timings.DNSLookup = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil {
+				t.timings.Connect = time.Since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timings.TLSHandshake = time.Since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timings.ConnReused = info.Reused
+			t.timings.ConnWasIdle = info.WasIdle
+			t.timings.ConnIdleTime = info.IdleTime
+			if info.Conn != nil {
+				t.timings.RemoteAddr = info.Conn.RemoteAddr().String()
+			}
+			t.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.mu.Lock()
+			t.wroteRequest = time.Now()
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			if !t.wroteRequest.IsZero() {
+				t.timings.ServerProcessing = t.firstByte.Sub(t.wroteRequest)
+			}
+			t.mu.Unlock()
+		},
+	})
+}
+
+// finish is called once the response body has been consumed.
+func (t *timingsTracer) finish() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if !t.firstByte.IsZero() {
+		t.timings.ContentTransfer = now.Sub(t.firstByte)
+	}
+	t.timings.Total = now.Sub(t.start)
+	return t.timings
+}
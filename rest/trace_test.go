@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_CollectTimings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, server.Client())
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	var observed []Timings
+	restClient.TimingsObserver = TimingsObserverFunc(func(_ *http.Request, timings Timings) {
+		observed = append(observed, timings)
+	})
+
+	for i := 0; i < 2; i++ {
+		result := restClient.Get().AbsPath("/timings").CollectTimings().Do(context.Background())
+		if result.err != nil {
+			t.Fatalf("do error: %s", result.err.Error())
+		}
+		timings := result.Timings()
+		if timings == nil {
+			t.Fatalf("timings not collected")
+		}
+		if timings.Total <= 0 || timings.RemoteAddr == "" {
+			t.Fatalf("unexpected timings: %+v", timings)
+		}
+	}
+
+	if len(observed) != 2 {
+		t.Fatalf("observer called %d times, want 2", len(observed))
+	}
+	if observed[0].ConnReused || observed[0].Connect <= 0 {
+		t.Fatalf("first request should dial a new connection: %+v", observed[0])
+	}
+	if !observed[1].ConnReused {
+		t.Fatalf("second request should reuse the connection: %+v", observed[1])
+	}
+}
@@ -0,0 +1,589 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a WebSocket data message.
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+
+	CloseNormalClosure = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseNoStatus      = 1005
+	CloseInvalidData   = 1007
+	CloseMessageTooBig = 1009
+
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	maxControlPayload      = 125
+	defaultMaxMessageBytes = 32 << 20
+	closeHandshakeTimeout  = 5 * time.Second
+)
+
+var (
+	ErrWebSocketClosed = errors.New("websocket: connection closed")
+
+	// ErrWebSocketProtocol is wrapped by errors for frames that violate
+	// RFC 6455. The connection is failed with CloseProtocolError.
+	ErrWebSocketProtocol = errors.New("websocket: protocol error")
+
+	// ErrWebSocketMessageTooBig is wrapped by errors for messages over the
+	// limit set by SetMaxMessageBytes. The connection is failed with
+	// CloseMessageTooBig.
+	ErrWebSocketMessageTooBig = errors.New("websocket: message too big")
+
+	// ErrWebSocketInvalidUTF8 is returned for a text message that is not
+	// valid UTF-8. The connection is failed with CloseInvalidData.
+	ErrWebSocketInvalidUTF8 = errors.New("websocket: invalid UTF-8 in text message")
+)
+
+// CloseError is returned by ReadMessage once the peer sent a close frame.
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: close %d %s", e.Code, e.Text)
+}
+
+// Upgrade performs the RFC 6455 opening handshake for the request's URL,
+// headers and auth over the client's transport, and returns the resulting
+// connection. The request timeout only bounds the handshake. The connection
+// counts as in flight for RESTClient.Close until it is closed.
+func (r *Request) Upgrade(ctx context.Context) (*WebSocketConn, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.verb == "" {
+		r.verb = http.MethodGet
+	}
+	if r.verb != http.MethodGet {
+		return nil, fmt.Errorf("websocket upgrade requires GET, got %s", r.verb)
+	}
+
+	if err := r.c.acquire(); err != nil {
+		return nil, err
+	}
+	upgraded := false
+	defer func() {
+		if !upgraded {
+			r.c.release()
+		}
+	}()
+
+	client := http.DefaultClient
+	if r.c.Client != nil {
+		client = r.c.Client
+	}
+	// http.Client.Timeout would wrap the switched body into a read-only
+	// reader, so the timeout is applied to the handshake context instead.
+	handshakeClient := *client
+	handshakeClient.Timeout = 0
+
+	ctx = r.withRequestID(ctx)
+	ctx = r.withTraceContext(ctx)
+
+	handshakeCtx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		handshakeCtx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	var netConn net.Conn
+	handshakeCtx = httptrace.WithClientTrace(handshakeCtx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			netConn = info.Conn
+		},
+	})
+
+	key := make([]byte, 16)
+	rand.Read(key)
+	challenge := base64.StdEncoding.EncodeToString(key)
+	r.SetHeader("Upgrade", "websocket")
+	r.SetHeader("Connection", "Upgrade")
+	r.SetHeader("Sec-WebSocket-Key", challenge)
+	r.SetHeader("Sec-WebSocket-Version", "13")
+
+	req, err := r.newHTTPRequest(handshakeCtx)
+	if err != nil {
+		return nil, err
+	}
+	r.logRequest(ctx)
+	resp, err := handshakeClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainBytes))
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, fmt.Errorf("websocket: transport does not support protocol switch")
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") ||
+		resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(challenge) {
+		rwc.Close()
+		return nil, fmt.Errorf("websocket: invalid handshake response")
+	}
+
+	conn := newWebSocketConn(rwc, netConn)
+	r.c.trackWebSocket(conn)
+	upgraded = true
+	return conn, nil
+}
+
+func websocketAccept(challenge string) string {
+	sum := sha1.Sum([]byte(challenge + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WebSocketConn is a message-oriented client WebSocket connection. One
+// goroutine may read while others write; ping and close frames from the
+// peer are answered from ReadMessage.
+type WebSocketConn struct {
+	rwc     io.ReadWriteCloser
+	netConn net.Conn
+	br      *bufio.Reader
+
+	readMu  sync.Mutex
+	writeMu sync.Mutex
+
+	mu            sync.Mutex
+	closeSent     bool
+	closeReceived bool
+	closed        bool
+	lastActivity  time.Time
+	keepaliveStop chan struct{}
+	onClose       func()
+
+	maxMessageBytes int64
+}
+
+func newWebSocketConn(rwc io.ReadWriteCloser, netConn net.Conn) *WebSocketConn {
+	return &WebSocketConn{
+		rwc:             rwc,
+		netConn:         netConn,
+		br:              bufio.NewReader(rwc),
+		lastActivity:    time.Now(),
+		maxMessageBytes: defaultMaxMessageBytes,
+	}
+}
+
+// SetMaxMessageBytes bounds the size of a reassembled message.
+func (c *WebSocketConn) SetMaxMessageBytes(n int64) {
+	c.maxMessageBytes = n
+}
+
+func (c *WebSocketConn) SetReadDeadline(t time.Time) error {
+	if c.netConn == nil {
+		return fmt.Errorf("websocket: deadlines are not supported by the transport")
+	}
+	return c.netConn.SetReadDeadline(t)
+}
+
+func (c *WebSocketConn) SetWriteDeadline(t time.Time) error {
+	if c.netConn == nil {
+		return fmt.Errorf("websocket: deadlines are not supported by the transport")
+	}
+	return c.netConn.SetWriteDeadline(t)
+}
+
+// ReadMessage returns the next data message, reassembling fragments.
+func (c *WebSocketConn) ReadMessage() (MessageType, []byte, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	var (
+		messageType MessageType
+		message     []byte
+		inMessage   bool
+	)
+	for {
+		frame, err := readFrame(c.br, c.maxMessageBytes, false)
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrWebSocketProtocol):
+				c.failProtocol()
+			case errors.Is(err, ErrWebSocketMessageTooBig):
+				// The payload was not read, so the stream is unusable.
+				c.fail(CloseMessageTooBig)
+			}
+			return 0, nil, err
+		}
+		c.touch()
+
+		switch frame.opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, frame.payload); err != nil {
+				return 0, nil, err
+			}
+		case opPong:
+		case opClose:
+			closeErr := parseClosePayload(frame.payload)
+			c.mu.Lock()
+			c.closeReceived = true
+			c.mu.Unlock()
+			c.sendClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case opText, opBinary:
+			if inMessage {
+				c.failProtocol()
+				return 0, nil, fmt.Errorf("%w: new message before previous one finished", ErrWebSocketProtocol)
+			}
+			messageType = MessageType(frame.opcode)
+			message = frame.payload
+			inMessage = true
+			if frame.fin {
+				return c.completeMessage(messageType, message)
+			}
+		case opContinuation:
+			if !inMessage {
+				c.failProtocol()
+				return 0, nil, fmt.Errorf("%w: unexpected continuation frame", ErrWebSocketProtocol)
+			}
+			if int64(len(message)+len(frame.payload)) > c.maxMessageBytes {
+				c.fail(CloseMessageTooBig)
+				return 0, nil, fmt.Errorf("%w: message exceeds %d bytes", ErrWebSocketMessageTooBig, c.maxMessageBytes)
+			}
+			message = append(message, frame.payload...)
+			if frame.fin {
+				return c.completeMessage(messageType, message)
+			}
+		default:
+			c.failProtocol()
+			return 0, nil, fmt.Errorf("%w: unknown opcode %d", ErrWebSocketProtocol, frame.opcode)
+		}
+	}
+}
+
+// completeMessage checks that a text message is valid UTF-8, which can
+// only be done once all fragments are in.
+func (c *WebSocketConn) completeMessage(messageType MessageType, message []byte) (MessageType, []byte, error) {
+	if messageType == TextMessage && !utf8.Valid(message) {
+		c.fail(CloseInvalidData)
+		return 0, nil, ErrWebSocketInvalidUTF8
+	}
+	return messageType, message, nil
+}
+
+func (c *WebSocketConn) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: unsupported message type %d", messageType)
+	}
+	c.mu.Lock()
+	closing := c.closeSent
+	c.mu.Unlock()
+	if closing {
+		return ErrWebSocketClosed
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// Ping sends a ping frame; the pong is consumed by ReadMessage.
+func (c *WebSocketConn) Ping(data []byte) error {
+	return c.writeFrame(opPing, data)
+}
+
+// KeepAlive pings the peer every interval and closes the connection when
+// nothing, pongs included, has been read for two intervals. A reader must be
+// running for pongs to be seen.
+func (c *WebSocketConn) KeepAlive(interval time.Duration) {
+	c.mu.Lock()
+	if c.keepaliveStop != nil || c.closed {
+		c.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	c.keepaliveStop = stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.mu.Lock()
+				idle := time.Since(c.lastActivity)
+				c.mu.Unlock()
+				if idle > 2*interval {
+					c.closeConn()
+					return
+				}
+				if err := c.Ping(nil); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Close performs the close handshake with a normal closure status.
+func (c *WebSocketConn) Close() error {
+	return c.CloseWithStatus(CloseNormalClosure, "")
+}
+
+// CloseWithStatus sends a close frame and waits, up to a few seconds, for
+// the peer's close frame before closing the connection. A concurrent
+// ReadMessage receives the peer's close frame as a CloseError.
+func (c *WebSocketConn) CloseWithStatus(code int, reason string) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	if err := c.sendClose(code, reason); err != nil {
+		c.closeConn()
+		return err
+	}
+
+	// The deadline also ends a concurrent ReadMessage holding readMu. The
+	// timer covers transports that do not expose the net.Conn.
+	if c.netConn != nil {
+		c.netConn.SetReadDeadline(time.Now().Add(closeHandshakeTimeout))
+	}
+	timer := time.AfterFunc(closeHandshakeTimeout, func() { c.closeConn() })
+	defer timer.Stop()
+	c.readMu.Lock()
+	for !c.isCloseReceived() {
+		frame, err := readFrame(c.br, c.maxMessageBytes, false)
+		if err != nil {
+			break
+		}
+		if frame.opcode == opClose {
+			c.mu.Lock()
+			c.closeReceived = true
+			c.mu.Unlock()
+		}
+	}
+	c.readMu.Unlock()
+	return c.closeConn()
+}
+
+func (c *WebSocketConn) isCloseReceived() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeReceived
+}
+
+func (c *WebSocketConn) sendClose(code int, reason string) error {
+	c.mu.Lock()
+	if c.closeSent {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closeSent = true
+	c.mu.Unlock()
+
+	var payload []byte
+	if code != CloseNoStatus {
+		payload = make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+	}
+	return c.writeFrame(opClose, payload)
+}
+
+func (c *WebSocketConn) failProtocol() {
+	c.fail(CloseProtocolError)
+}
+
+// fail sends a close frame with code and closes the connection without
+// waiting for the peer's close.
+func (c *WebSocketConn) fail(code int) {
+	c.sendClose(code, "")
+	c.closeConn()
+}
+
+func (c *WebSocketConn) closeConn() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	if c.keepaliveStop != nil {
+		close(c.keepaliveStop)
+	}
+	onClose := c.onClose
+	c.mu.Unlock()
+	err := c.rwc.Close()
+	if onClose != nil {
+		onClose()
+	}
+	return err
+}
+
+func (c *WebSocketConn) touch() {
+	c.mu.Lock()
+	c.lastActivity = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *WebSocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return ErrWebSocketClosed
+	}
+	return writeFrame(c.rwc, opcode, payload, true)
+}
+
+func parseClosePayload(payload []byte) *CloseError {
+	if len(payload) < 2 {
+		return &CloseError{Code: CloseNoStatus}
+	}
+	return &CloseError{
+		Code: int(binary.BigEndian.Uint16(payload)),
+		Text: string(payload[2:]),
+	}
+}
+
+type wsFrame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+// readFrame reads one frame. Frames from a client are masked and frames
+// from a server are not; anything else fails with ErrWebSocketProtocol.
+func readFrame(r io.Reader, maxPayload int64, wantMasked bool) (wsFrame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return wsFrame{}, err
+	}
+	frame := wsFrame{
+		fin:    header[0]&0x80 != 0,
+		opcode: header[0] & 0x0f,
+	}
+	if header[0]&0x70 != 0 {
+		return wsFrame{}, fmt.Errorf("%w: reserved bits set", ErrWebSocketProtocol)
+	}
+	masked := header[1]&0x80 != 0
+	if masked != wantMasked {
+		return wsFrame{}, fmt.Errorf("%w: unexpected mask bit %v", ErrWebSocketProtocol, masked)
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return wsFrame{}, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return wsFrame{}, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if frame.opcode >= opClose && (length > maxControlPayload || !frame.fin) {
+		return wsFrame{}, fmt.Errorf("%w: invalid control frame", ErrWebSocketProtocol)
+	}
+	if length < 0 || length > maxPayload {
+		return wsFrame{}, fmt.Errorf("%w: frame exceeds %d bytes", ErrWebSocketMessageTooBig, maxPayload)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return wsFrame{}, err
+		}
+	}
+	frame.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, frame.payload); err != nil {
+		return wsFrame{}, err
+	}
+	if masked {
+		maskBytes(mask, frame.payload)
+	}
+	return frame, nil
+}
+
+// writeFrame writes a single final frame. Clients must mask their frames,
+// servers must not.
+func writeFrame(w io.Writer, opcode byte, payload []byte, mask bool) error {
+	buf := make([]byte, 0, 14+len(payload))
+	buf = append(buf, 0x80|opcode)
+
+	var maskBit byte
+	if mask {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		buf = append(buf, maskBit|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		buf = append(buf, maskBit|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(payload)))
+	default:
+		buf = append(buf, maskBit|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(len(payload)))
+	}
+
+	if mask {
+		var key [4]byte
+		rand.Read(key[:])
+		buf = append(buf, key[:]...)
+		start := len(buf)
+		buf = append(buf, payload...)
+		maskBytes(key, buf[start:])
+	} else {
+		buf = append(buf, payload...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
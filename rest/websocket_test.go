@@ -0,0 +1,316 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoWebSocket is a minimal RFC 6455 server: it echoes data messages,
+// answers "ping-me" by pinging the client and reporting the pong, and
+// echoes the close frame.
+func echoWebSocket(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack error: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+
+		reader := bufio.NewReader(rw)
+		for {
+			frame, err := readFrame(reader, defaultMaxMessageBytes, true)
+			if err != nil {
+				return
+			}
+			switch frame.opcode {
+			case opText, opBinary:
+				if string(frame.payload) == "ping-me" {
+					writeFrame(conn, opPing, []byte("hello"), false)
+					continue
+				}
+				writeFrame(conn, frame.opcode, frame.payload, false)
+			case opPong:
+				writeFrame(conn, opText, append([]byte("pong:"), frame.payload...), false)
+			case opClose:
+				writeFrame(conn, opClose, frame.payload, false)
+				return
+			}
+		}
+	})
+}
+
+func Test_WebSocketUpgrade(t *testing.T) {
+	server := httptest.NewServer(echoWebSocket(t))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, err := NewRESTClient(hostURL, &http.Client{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new rest client error: %s", err.Error())
+	}
+
+	if _, err := restClient.Get().AbsPath("/ws").Upgrade(context.Background()); err == nil {
+		t.Fatalf("expected unauthorized upgrade to fail")
+	}
+
+	conn, err := restClient.Get().AbsPath("/ws").SetHeader("Authorization", "Bearer token").Upgrade(context.Background())
+	if err != nil {
+		t.Fatalf("upgrade error: %s", err.Error())
+	}
+	// Outlive the client timeout to make sure it only bounds the handshake.
+	time.Sleep(100 * time.Millisecond)
+
+	if err := conn.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("set read deadline error: %s", err.Error())
+	}
+
+	large := strings.Repeat("x", 70000)
+	for _, message := range []string{"hello", large} {
+		if err := conn.WriteMessage(TextMessage, []byte(message)); err != nil {
+			t.Fatalf("write error: %s", err.Error())
+		}
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read error: %s", err.Error())
+		}
+		if messageType != TextMessage || string(data) != message {
+			t.Fatalf("unexpected echo of %d bytes: type %d, %d bytes", len(message), messageType, len(data))
+		}
+	}
+
+	conn.WriteMessage(TextMessage, []byte("ping-me"))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read error: %s", err.Error())
+	}
+	if string(data) != "pong:hello" {
+		t.Fatalf("server did not get pong, got %q", data)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); err != ErrWebSocketClosed {
+		t.Fatalf("write after close = %v, want ErrWebSocketClosed", err)
+	}
+}
+
+func Test_WebSocketReadDeadline(t *testing.T) {
+	server := httptest.NewServer(echoWebSocket(t))
+	defer server.Close()
+
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+	conn, err := restClient.Get().AbsPath("/ws").SetHeader("Authorization", "Bearer token").Upgrade(context.Background())
+	if err != nil {
+		t.Fatalf("upgrade error: %s", err.Error())
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, _, err := conn.ReadMessage(); err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Fatalf("expected read timeout, got %v", err)
+	}
+}
+
+// scriptedWebSocket completes the handshake and hands the raw connection
+// to serve.
+func scriptedWebSocket(t *testing.T, serve func(conn net.Conn, reader *bufio.Reader)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("hijack error: %s", err.Error())
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + websocketAccept(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		serve(conn, rw.Reader)
+	}))
+}
+
+func upgradeScripted(t *testing.T, server *httptest.Server) (*RESTClient, *WebSocketConn) {
+	t.Helper()
+	hostURL, _ := url.Parse(server.URL)
+	restClient, _ := NewRESTClient(hostURL, server.Client())
+	conn, err := restClient.Get().AbsPath("/ws").Upgrade(context.Background())
+	if err != nil {
+		t.Fatalf("upgrade error: %s", err.Error())
+	}
+	return restClient, conn
+}
+
+func Test_WebSocketRejectsMaskedFrames(t *testing.T) {
+	closeCode := make(chan int, 1)
+	server := scriptedWebSocket(t, func(conn net.Conn, reader *bufio.Reader) {
+		writeFrame(conn, opText, []byte("masked"), true)
+		frame, err := readFrame(reader, defaultMaxMessageBytes, true)
+		if err == nil && frame.opcode == opClose {
+			closeCode <- parseClosePayload(frame.payload).Code
+		}
+		close(closeCode)
+	})
+	defer server.Close()
+
+	_, conn := upgradeScripted(t, server)
+	if _, _, err := conn.ReadMessage(); !errors.Is(err, ErrWebSocketProtocol) {
+		t.Fatalf("read of masked frame = %v, want ErrWebSocketProtocol", err)
+	}
+	if code := <-closeCode; code != CloseProtocolError {
+		t.Fatalf("close code = %d, want %d", code, CloseProtocolError)
+	}
+}
+
+func Test_WebSocketKeepAlive(t *testing.T) {
+	pings := make(chan struct{}, 16)
+	server := scriptedWebSocket(t, func(conn net.Conn, reader *bufio.Reader) {
+		answered := 0
+		for {
+			frame, err := readFrame(reader, defaultMaxMessageBytes, true)
+			if err != nil {
+				return
+			}
+			if frame.opcode != opPing {
+				continue
+			}
+			pings <- struct{}{}
+			// Answer the first two pings, then go silent.
+			if answered < 2 {
+				answered++
+				writeFrame(conn, opPong, frame.payload, false)
+			}
+		}
+	})
+	defer server.Close()
+
+	restClient, conn := upgradeScripted(t, server)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	const interval = 20 * time.Millisecond
+	start := time.Now()
+	conn.KeepAlive(interval)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-pings:
+		case <-time.After(time.Second):
+			t.Fatalf("ping %d not sent", i+1)
+		}
+	}
+	select {
+	case <-readErr:
+	case <-time.After(time.Second):
+		t.Fatalf("connection not closed after missing pongs")
+	}
+	if elapsed := time.Since(start); elapsed < 3*interval {
+		t.Fatalf("connection closed after %v, before pongs were missed", elapsed)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); err != ErrWebSocketClosed {
+		t.Fatalf("write after keepalive close = %v, want ErrWebSocketClosed", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := restClient.Close(ctx); err != nil {
+		t.Fatalf("close after websocket closed = %v", err)
+	}
+}
+
+func Test_CloseClosesWebSockets(t *testing.T) {
+	closeCode := make(chan int, 1)
+	server := scriptedWebSocket(t, func(conn net.Conn, reader *bufio.Reader) {
+		for {
+			frame, err := readFrame(reader, defaultMaxMessageBytes, true)
+			if err != nil {
+				return
+			}
+			if frame.opcode == opClose {
+				closeCode <- parseClosePayload(frame.payload).Code
+				writeFrame(conn, opClose, frame.payload, false)
+				return
+			}
+		}
+	})
+	defer server.Close()
+
+	restClient, conn := upgradeScripted(t, server)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := restClient.Close(ctx); err != nil {
+		t.Fatalf("close error: %s", err.Error())
+	}
+	if code := <-closeCode; code != CloseGoingAway {
+		t.Fatalf("close code = %d, want %d", code, CloseGoingAway)
+	}
+	if err := conn.WriteMessage(TextMessage, []byte("late")); err != ErrWebSocketClosed {
+		t.Fatalf("write after client close = %v, want ErrWebSocketClosed", err)
+	}
+}
+
+func Test_WebSocketFailsBadMessages(t *testing.T) {
+	cases := []struct {
+		name    string
+		send    func(conn net.Conn)
+		wantErr error
+		code    int
+	}{
+		{"oversized frame", func(conn net.Conn) { writeFrame(conn, opBinary, make([]byte, 64), false) }, ErrWebSocketMessageTooBig, CloseMessageTooBig},
+		{"invalid UTF-8", func(conn net.Conn) { writeFrame(conn, opText, []byte{'a', 0xff, 'b'}, false) }, ErrWebSocketInvalidUTF8, CloseInvalidData},
+	}
+	for _, tc := range cases {
+		closeCode := make(chan int, 1)
+		server := scriptedWebSocket(t, func(conn net.Conn, reader *bufio.Reader) {
+			tc.send(conn)
+			frame, err := readFrame(reader, defaultMaxMessageBytes, true)
+			if err == nil && frame.opcode == opClose {
+				closeCode <- parseClosePayload(frame.payload).Code
+			}
+			close(closeCode)
+		})
+
+		_, conn := upgradeScripted(t, server)
+		conn.SetMaxMessageBytes(32)
+		if _, _, err := conn.ReadMessage(); !errors.Is(err, tc.wantErr) {
+			t.Fatalf("%s: read error = %v, want %v", tc.name, err, tc.wantErr)
+		}
+		if code := <-closeCode; code != tc.code {
+			t.Fatalf("%s: close code = %d, want %d", tc.name, code, tc.code)
+		}
+		// The connection is failed, so no garbage is parsed afterwards.
+		if _, _, err := conn.ReadMessage(); err == nil {
+			t.Fatalf("%s: read after failure succeeded", tc.name)
+		}
+		server.Close()
+	}
+}